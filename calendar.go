@@ -9,6 +9,8 @@ import (
 	"io"
 	"net/http"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 )
@@ -57,6 +59,7 @@ const (
 	ComponentPropertyRrule           = ComponentProperty(PropertyRrule)
 	ComponentPropertyAction          = ComponentProperty(PropertyAction)
 	ComponentPropertyTrigger         = ComponentProperty(PropertyTrigger)
+	ComponentPropertyRepeat          = ComponentProperty(PropertyRepeat)
 	ComponentPropertyPriority        = ComponentProperty(PropertyPriority)
 	ComponentPropertyResources       = ComponentProperty(PropertyResources)
 	ComponentPropertyCompleted       = ComponentProperty(PropertyCompleted)
@@ -71,28 +74,59 @@ const (
 	ComponentPropertyContact         = ComponentProperty(PropertyContact)
 	ComponentPropertyRequestStatus   = ComponentProperty(PropertyRequestStatus)
 	ComponentPropertyRDate           = ComponentProperty(PropertyRdate)
+	ComponentPropertyTzname          = ComponentProperty(PropertyTzname)
+	ComponentPropertyTzoffsetfrom    = ComponentProperty(PropertyTzoffsetfrom)
+	ComponentPropertyTzoffsetto      = ComponentProperty(PropertyTzoffsetto)
+	ComponentPropertyXAltDesc        = ComponentProperty(PropertyXAltDesc) // TEXT
 )
 
 // Required returns the rules from the RFC as to if they are required or not for any particular component type
 // If unspecified or incomplete, it returns false. -- This list is incomplete verify source. Happy to take PRs with reference
 // iana-prop and x-props are not covered as it would always be true and require an exhaustive list.
+// The rules are those of RFC5545 for a calendar without a METHOD, see RequiredFor for scheduling messages.
 func (cp ComponentProperty) Required(c Component) bool {
-	// https://www.rfc-editor.org/rfc/rfc5545#section-3.6.1
-	switch cp {
-	case ComponentPropertyDtstamp, ComponentPropertyUniqueId:
-		switch c.(type) {
-		case *VEvent:
-			return true
-		}
-	case ComponentPropertyDtStart:
-		switch c := c.(type) {
-		case *VEvent:
-			return !c.HasProperty(ComponentPropertyMethod)
+	return cp.RequiredFor(c, "")
+}
+
+// RequiredFor is Required for a component of a calendar with the given METHOD, as returned by Calendar.GetMethod. A
+// METHOD makes it a scheduling message, where RFC5546 rather than RFC5545 determines what is required.
+func (cp ComponentProperty) RequiredFor(c Component, method Method) bool {
+	switch c.(type) {
+	case *VEvent:
+		for _, required := range eventRequiredProperties(method) {
+			if cp == required {
+				return true
+			}
 		}
 	}
 	return false
 }
 
+// methodEventProperties are the properties RFC5546 requires of a VEVENT in a scheduling message with the METHOD
+var methodEventProperties = map[Method][]ComponentProperty{
+	MethodPublish:        {ComponentPropertyDtstamp, ComponentPropertyDtStart, ComponentPropertyOrganizer, ComponentPropertySummary, ComponentPropertyUniqueId},
+	MethodRequest:        {ComponentPropertyAttendee, ComponentPropertyDtstamp, ComponentPropertyDtStart, ComponentPropertyOrganizer, ComponentPropertySummary, ComponentPropertyUniqueId},
+	MethodReply:          {ComponentPropertyAttendee, ComponentPropertyDtstamp, ComponentPropertyOrganizer, ComponentPropertyUniqueId},
+	MethodAdd:            {ComponentPropertyDtstamp, ComponentPropertyDtStart, ComponentPropertyOrganizer, ComponentPropertySequence, ComponentPropertySummary, ComponentPropertyUniqueId},
+	MethodCancel:         {ComponentPropertyDtstamp, ComponentPropertyOrganizer, ComponentPropertySequence, ComponentPropertyUniqueId},
+	MethodRefresh:        {ComponentPropertyAttendee, ComponentPropertyDtstamp, ComponentPropertyOrganizer, ComponentPropertyUniqueId},
+	MethodCounter:        {ComponentPropertyDtstamp, ComponentPropertyDtStart, ComponentPropertyOrganizer, ComponentPropertySummary, ComponentPropertyUniqueId},
+	MethodDeclinecounter: {ComponentPropertyDtstamp, ComponentPropertyOrganizer, ComponentPropertyUniqueId},
+}
+
+// eventRequiredProperties returns the properties a VEVENT requires in a calendar with the METHOD. Without a METHOD these
+// are the RFC5545 section 3.6.1 rules, with an unknown METHOD only those common to every RFC5546 method.
+func eventRequiredProperties(method Method) []ComponentProperty {
+	if required, ok := methodEventProperties[method]; ok {
+		return required
+	}
+	if method == "" {
+		// https://www.rfc-editor.org/rfc/rfc5545#section-3.6.1
+		return []ComponentProperty{ComponentPropertyDtstamp, ComponentPropertyDtStart, ComponentPropertyUniqueId}
+	}
+	return []ComponentProperty{ComponentPropertyDtstamp, ComponentPropertyUniqueId}
+}
+
 // Exclusive returns the ComponentProperty's using the rules from the RFC as to if one or more existing properties are prohibiting this one
 // If unspecified or incomplete, it returns false. -- This list is incomplete verify source. Happy to take PRs with reference
 // iana-prop and x-props are not covered as it would always be true and require an exhaustive list.
@@ -119,15 +153,23 @@ func (cp ComponentProperty) Exclusive(c Component) []ComponentProperty {
 
 // Singular returns the rules from the RFC as to if the spec states that if "Must not occur more than once"
 // iana-prop and x-props are not covered as it would always be true and require an exhaustive list.
+// c may be nil when the component type is not known, in which case only the properties which may not occur more than
+// once in any component are singular.
 func (cp ComponentProperty) Singular(c Component) bool {
-	// https://www.rfc-editor.org/rfc/rfc5545#section-3.6.1
+	// https://www.rfc-editor.org/rfc/rfc5545#section-3.6
 	switch cp {
-	case ComponentPropertyClass, ComponentPropertyCreated, ComponentPropertyDescription, ComponentPropertyGeo,
-		ComponentPropertyLastModified, ComponentPropertyLocation, ComponentPropertyOrganizer, ComponentPropertyPriority,
-		ComponentPropertySequence, ComponentPropertyStatus, ComponentPropertySummary, ComponentPropertyTransp,
-		ComponentPropertyUrl, ComponentPropertyRecurrenceId:
+	case ComponentPropertyAction, ComponentPropertyClass, ComponentPropertyCompleted, ComponentPropertyCreated,
+		ComponentPropertyDtstamp, ComponentPropertyDtEnd, ComponentPropertyDtStart, ComponentPropertyDue,
+		ComponentPropertyDuration, ComponentPropertyGeo, ComponentPropertyLastModified, ComponentPropertyLocation,
+		ComponentPropertyOrganizer, ComponentPropertyPercentComplete, ComponentPropertyPriority,
+		ComponentPropertyRecurrenceId, ComponentPropertyRepeat, ComponentPropertySequence, ComponentPropertyStatus,
+		ComponentPropertySummary, ComponentPropertyTransp, ComponentPropertyTrigger, ComponentPropertyTzid,
+		ComponentPropertyUniqueId, ComponentPropertyUrl:
+		return true
+	case ComponentPropertyDescription:
+		// A VJOURNAL may have several
 		switch c.(type) {
-		case *VEvent:
+		case *VEvent, *VTodo, *VAlarm:
 			return true
 		}
 	}
@@ -182,7 +224,7 @@ const (
 	PropertyProductId       Property = "PRODID"   // TEXT
 	PropertyVersion         Property = "VERSION"  // TEXT
 	PropertyXPublishedTTL   Property = "X-PUBLISHED-TTL"
-	PropertyRefreshInterval Property = "REFRESH-INTERVAL;VALUE=DURATION"
+	PropertyRefreshInterval Property = "REFRESH-INTERVAL"
 	PropertyAttach          Property = "ATTACH"
 	PropertyCategories      Property = "CATEGORIES"  // TEXT
 	PropertyClass           Property = "CLASS"       // TEXT
@@ -233,13 +275,45 @@ const (
 	PropertySequence        Property = "SEQUENCE"
 	PropertyXWRCalID        Property = "X-WR-RELCALID"
 	PropertyTimezoneId      Property = "TIMEZONE-ID"
+	PropertySource          Property = "SOURCE"
+	PropertyImage           Property = "IMAGE"
+	PropertyConference      Property = "CONFERENCE"
+	PropertyXAltDesc        Property = "X-ALT-DESC" // TEXT
 )
 
+var knownProperties = map[Property]struct{}{}
+
+func init() {
+	for _, p := range []Property{
+		PropertyCalscale, PropertyMethod, PropertyProductId, PropertyVersion, PropertyXPublishedTTL,
+		PropertyRefreshInterval, PropertyAttach, PropertyCategories, PropertyClass, PropertyColor, PropertyComment,
+		PropertyDescription, PropertyXWRCalDesc, PropertyGeo, PropertyLocation, PropertyPercentComplete,
+		PropertyPriority, PropertyResources, PropertyStatus, PropertySummary, PropertyCompleted, PropertyDtend,
+		PropertyDue, PropertyDtstart, PropertyDuration, PropertyFreebusy, PropertyTransp, PropertyTzid,
+		PropertyTzname, PropertyTzoffsetfrom, PropertyTzoffsetto, PropertyTzurl, PropertyAttendee, PropertyContact,
+		PropertyOrganizer, PropertyRecurrenceId, PropertyRelatedTo, PropertyUrl, PropertyUid, PropertyExdate,
+		PropertyExrule, PropertyRdate, PropertyRrule, PropertyAction, PropertyRepeat, PropertyTrigger,
+		PropertyCreated, PropertyDtstamp, PropertyLastModified, PropertyRequestStatus, PropertyName,
+		PropertyXWRCalName, PropertyXWRTimezone, PropertySequence, PropertyXWRCalID, PropertyTimezoneId,
+		PropertySource, PropertyImage, PropertyConference, PropertyXAltDesc,
+	} {
+		knownProperties[p] = struct{}{}
+	}
+}
+
+// IsKnown returns true if the property is one of the Property constants defined by this package
+func (p Property) IsKnown() bool {
+	_, ok := knownProperties[p]
+	return ok
+}
+
 type Parameter string
 
+// IsQuoted returns true for parameters whose values are URIs or cal-addresses, which RFC5545 requires to be quoted.
 func (p Parameter) IsQuoted() bool {
 	switch p {
-	case ParameterAltrep:
+	case ParameterAltrep, ParameterDelegatedFrom, ParameterDelegatedTo, ParameterDir, ParameterMember, ParameterSentBy,
+		ParameterScheduleStatus:
 		return true
 	}
 	return false
@@ -263,6 +337,9 @@ const (
 	ParameterReltype             Parameter = "RELTYPE"
 	ParameterRole                Parameter = "ROLE"
 	ParameterRsvp                Parameter = "RSVP"
+	ParameterScheduleAgent       Parameter = "SCHEDULE-AGENT"
+	ParameterScheduleForceSend   Parameter = "SCHEDULE-FORCE-SEND"
+	ParameterScheduleStatus      Parameter = "SCHEDULE-STATUS"
 	ParameterSentBy              Parameter = "SENT-BY"
 	ParameterTzid                Parameter = "TZID"
 	ParameterValue               Parameter = "VALUE"
@@ -364,6 +441,19 @@ func (pr ParticipationRole) KeyValue(_ ...interface{}) (string, []string) {
 	return string(ParameterRole), []string{string(pr)}
 }
 
+// RecurrenceRange is the RANGE parameter of a RECURRENCE-ID. THISANDPRIOR is deprecated by RFC5545 but may still be
+// found in older data.
+type RecurrenceRange string
+
+const (
+	RecurrenceRangeThisAndFuture RecurrenceRange = "THISANDFUTURE"
+	RecurrenceRangeThisAndPrior  RecurrenceRange = "THISANDPRIOR"
+)
+
+func (rr RecurrenceRange) KeyValue(_ ...interface{}) (string, []string) {
+	return string(ParameterRange), []string{string(rr)}
+}
+
 type Action string
 
 const (
@@ -417,6 +507,51 @@ func NewCalendarFor(service string) *Calendar {
 	return c
 }
 
+// NewMeeting builds a METHOD:REQUEST calendar containing a single event suitable for sending as a meeting invite. Each
+// attendee is added as a required participant with RSVP requested. DTSTAMP is the current time, taken from WithClock if
+// given.
+func NewMeeting(uid, summary string, start, end time.Time, organizer string, attendees []string, ops ...any) (*Calendar, error) {
+	generateConfig, err := parseGenerateOps(ops)
+	if err != nil {
+		return nil, err
+	}
+	cal := NewCalendar()
+	cal.SetMethod(MethodRequest)
+	event := cal.AddEvent(uid)
+	event.SetDtStampTime(generateConfig.Clock())
+	event.SetStartAt(start)
+	event.SetEndAt(end)
+	event.SetSummary(summary)
+	event.SetOrganizer(organizer)
+	for _, attendee := range attendees {
+		event.AddAttendee(attendee, CalendarUserTypeIndividual, ParticipationStatusNeedsAction, ParticipationRoleReqParticipant, WithRSVP(true))
+	}
+	return cal, nil
+}
+
+// NewFreeBusyRequest returns a METHOD:REQUEST calendar with a VFREEBUSY asking for the busy time of attendee between
+// from and to, as described by RFC5546 section 3.3.2. The UID and DTSTAMP are generated as by NewGeneratedEvent, so
+// WithRandomSource and WithClock apply.
+func NewFreeBusyRequest(organizer, attendee string, from, to time.Time, ops ...any) (*Calendar, error) {
+	generateConfig, err := parseGenerateOps(ops)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := generateConfig.newUID()
+	if err != nil {
+		return nil, err
+	}
+	cal := NewCalendar()
+	cal.SetMethod(MethodRequest)
+	busy := cal.AddBusy(uid)
+	busy.SetDtStampTime(generateConfig.Clock())
+	busy.SetStartAt(from)
+	busy.SetEndAt(to)
+	busy.SetOrganizer(organizer)
+	busy.AddAttendee(attendee)
+	return cal, nil
+}
+
 func (cal *Calendar) Serialize(ops ...any) string {
 	b := bytes.NewBufferString("")
 	// We are intentionally ignoring the return value. _ used to communicate this to lint.
@@ -427,25 +562,145 @@ func (cal *Calendar) Serialize(ops ...any) string {
 type WithLineLength int
 type WithNewLine string
 
+// WithForceUTC see SerializationConfiguration.ForceUTC
+type WithForceUTC bool
+
+// WithCanonicalPropertyOrder see SerializationConfiguration.CanonicalPropertyOrder
+type WithCanonicalPropertyOrder bool
+
+// WithNoTrailingNewline see SerializationConfiguration.OmitTrailingNewline
+type WithNoTrailingNewline bool
+
+// WithVersionProdIdFirst see SerializationConfiguration.VersionProdIdFirst
+type WithVersionProdIdFirst bool
+
 func (cal *Calendar) SerializeTo(w io.Writer, ops ...any) error {
+	return cal.SerializeToContext(context.Background(), w, ops...)
+}
+
+// EstimatedSize returns the length in bytes Serialize would return with the same ops, without holding the output in
+// memory, for deciding whether the calendar needs trimming before being sent to size limited clients.
+func (cal *Calendar) EstimatedSize(ops ...any) int {
+	var w countingWriter
+	// As with Serialize the error is intentionally ignored, the size is that of what was written.
+	_ = cal.SerializeTo(&w, ops...)
+	return int(w)
+}
+
+// countingWriter discards what is written to it, counting the bytes
+type countingWriter int
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// SerializeRFC serializes the calendar with CRLF line endings as RFC5545 requires, regardless of the platform default
+// or any WithNewLine in ops.
+func (cal *Calendar) SerializeRFC(ops ...any) string {
+	serializeConfig, err := parseSerializeOps(ops)
+	if err != nil {
+		// As with Serialize, invalid ops produce no output
+		return ""
+	}
+	rfcConfig := *serializeConfig
+	rfcConfig.NewLine = string(WithNewLineWindows)
+	return cal.Serialize(&rfcConfig)
+}
+
+// SerializeToContext is SerializeTo but stops with the context's error if ctx is done before all the top level
+// components have been written.
+func (cal *Calendar) SerializeToContext(ctx context.Context, w io.Writer, ops ...any) error {
 	serializeConfig, err := parseSerializeOps(ops)
 	if err != nil {
 		return err
 	}
+	if serializeConfig.ForceUTC {
+		serializeConfig = cal.withTimezoneLocations(serializeConfig)
+	}
 	_, _ = fmt.Fprint(w, "BEGIN:VCALENDAR", serializeConfig.NewLine)
-	for _, p := range cal.CalendarProperties {
+	for _, p := range cal.orderedProperties(serializeConfig) {
 		err := p.serialize(w, serializeConfig)
 		if err != nil {
 			return err
 		}
 	}
 	for _, c := range cal.Components {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		err := c.SerializeTo(w, serializeConfig)
 		if err != nil {
 			return err
 		}
 	}
-	_, _ = fmt.Fprint(w, "END:VCALENDAR", serializeConfig.NewLine)
+	if serializeConfig.OmitTrailingNewline {
+		_, _ = fmt.Fprint(w, "END:VCALENDAR")
+	} else {
+		_, _ = fmt.Fprint(w, "END:VCALENDAR", serializeConfig.NewLine)
+	}
+	return nil
+}
+
+// withTimezoneLocations returns a copy of the configuration which resolves the TZIDs of the calendar's VTIMEZONEs
+func (cal *Calendar) withTimezoneLocations(serializeConfig *SerializationConfiguration) *SerializationConfiguration {
+	config := *serializeConfig
+	config.locations = map[string]*time.Location{}
+	for _, tz := range cal.Timezones() {
+		p := tz.GetProperty(ComponentPropertyTzid)
+		if p == nil {
+			continue
+		}
+		if _, ok := config.locations[p.Value]; ok {
+			continue
+		}
+		if loc, err := tz.ToLocation(); err == nil {
+			config.locations[p.Value] = loc
+		}
+	}
+	return &config
+}
+
+func (cal *Calendar) orderedProperties(serializeConfig *SerializationConfiguration) []CalendarProperty {
+	if !serializeConfig.VersionProdIdFirst {
+		return cal.CalendarProperties
+	}
+	rank := func(p CalendarProperty) int {
+		switch Property(p.IANAToken) {
+		case PropertyVersion:
+			return 0
+		case PropertyProductId:
+			return 1
+		}
+		return 2
+	}
+	properties := make([]CalendarProperty, len(cal.CalendarProperties))
+	copy(properties, cal.CalendarProperties)
+	sort.SliceStable(properties, func(i, j int) bool {
+		return rank(properties[i]) < rank(properties[j])
+	})
+	return properties
+}
+
+// SerializeCalendars writes several calendars back to back as a single stream of VCALENDAR objects, as carried by some
+// email and MIME messages. Every calendar but the last ends with a newline even with WithNoTrailingNewline, so the
+// objects are always separated.
+func SerializeCalendars(w io.Writer, cals []*Calendar, ops ...any) error {
+	serializeConfig, err := parseSerializeOps(ops)
+	if err != nil {
+		return err
+	}
+	between := *serializeConfig
+	between.OmitTrailingNewline = false
+	for i, cal := range cals {
+		config := &between
+		if i == len(cals)-1 {
+			config = serializeConfig
+		}
+		if err := cal.SerializeTo(w, config); err != nil {
+			return fmt.Errorf("calendar %d: %w", i, err)
+		}
+	}
 	return nil
 }
 
@@ -453,6 +708,21 @@ type SerializationConfiguration struct {
 	MaxLength         int
 	NewLine           string
 	PropertyMaxLength int
+	// ForceUTC converts DATE-TIME values with a TZID parameter into UTC while writing, dropping the TZID. TZIDs are
+	// resolved through the calendar's VTIMEZONEs first, then time.LoadLocation. The calendar itself is not modified.
+	// Values whose TZID can not be resolved are written unchanged.
+	ForceUTC bool
+	// CanonicalPropertyOrder writes the properties of each component in a conventional order (UID, DTSTAMP, DTSTART, ...)
+	// with any remaining properties following in their stored order. The components themselves are not modified.
+	CanonicalPropertyOrder bool
+	// OmitTrailingNewline leaves off the new line after END:VCALENDAR for consumers requiring byte exact output.
+	OmitTrailingNewline bool
+	// VersionProdIdFirst writes the calendar's VERSION then PRODID before its other properties, for importers expecting
+	// them early. The calendar itself is not modified.
+	VersionProdIdFirst bool
+
+	// locations holds the zones defined by the VTIMEZONEs of the calendar being written, for ForceUTC
+	locations map[string]*time.Location
 }
 
 func parseSerializeOps(ops []any) (*SerializationConfiguration, error) {
@@ -463,6 +733,14 @@ func parseSerializeOps(ops []any) (*SerializationConfiguration, error) {
 			serializeConfig.MaxLength = int(op)
 		case WithNewLine:
 			serializeConfig.NewLine = string(op)
+		case WithForceUTC:
+			serializeConfig.ForceUTC = bool(op)
+		case WithCanonicalPropertyOrder:
+			serializeConfig.CanonicalPropertyOrder = bool(op)
+		case WithNoTrailingNewline:
+			serializeConfig.OmitTrailingNewline = bool(op)
+		case WithVersionProdIdFirst:
+			serializeConfig.VersionProdIdFirst = bool(op)
 		case *SerializationConfiguration:
 			return op, nil
 		case error:
@@ -487,6 +765,14 @@ func (cal *Calendar) SetMethod(method Method, params ...PropertyParameter) {
 	cal.setProperty(PropertyMethod, string(method), params...)
 }
 
+// GetMethod returns the iTIP METHOD of the calendar in upper case, or "" if it is not a scheduling message.
+func (cal *Calendar) GetMethod() Method {
+	if p := cal.getProperty(PropertyMethod); p != nil {
+		return Method(strings.ToUpper(p.Value))
+	}
+	return ""
+}
+
 func (cal *Calendar) SetXPublishedTTL(s string, params ...PropertyParameter) {
 	cal.setProperty(PropertyXPublishedTTL, s, params...)
 }
@@ -499,11 +785,38 @@ func (cal *Calendar) SetProductId(s string, params ...PropertyParameter) {
 	cal.setProperty(PropertyProductId, s, params...)
 }
 
+// SetName sets both the RFC7986 NAME and Apple's X-WR-CALNAME. Use SetStandardName or SetAppleCalName to set only one.
 func (cal *Calendar) SetName(s string, params ...PropertyParameter) {
 	cal.setProperty(PropertyName, s, params...)
 	cal.setProperty(PropertyXWRCalName, s, params...)
 }
 
+// SetStandardName sets only the RFC7986 NAME, for consumers which reject X- properties.
+func (cal *Calendar) SetStandardName(s string, params ...PropertyParameter) {
+	cal.setProperty(PropertyName, s, params...)
+}
+
+// GetStandardName returns the RFC7986 NAME, or "" if not set.
+func (cal *Calendar) GetStandardName() string {
+	if p := cal.getProperty(PropertyName); p != nil {
+		return p.Value
+	}
+	return ""
+}
+
+// SetAppleCalName sets only X-WR-CALNAME, the same as SetXWRCalName.
+func (cal *Calendar) SetAppleCalName(s string, params ...PropertyParameter) {
+	cal.SetXWRCalName(s, params...)
+}
+
+// GetAppleCalName returns X-WR-CALNAME, or "" if not set.
+func (cal *Calendar) GetAppleCalName() string {
+	if p := cal.getProperty(PropertyXWRCalName); p != nil {
+		return p.Value
+	}
+	return ""
+}
+
 func (cal *Calendar) SetColor(s string, params ...PropertyParameter) {
 	cal.setProperty(PropertyColor, s, params...)
 }
@@ -532,8 +845,19 @@ func (cal *Calendar) SetLastModified(t time.Time, params ...PropertyParameter) {
 	cal.setProperty(PropertyLastModified, t.UTC().Format(icalTimestampFormatUtc), params...)
 }
 
+// SetRefreshInterval sets REFRESH-INTERVAL to the DURATION value s, adding the VALUE=DURATION parameter it requires.
 func (cal *Calendar) SetRefreshInterval(s string, params ...PropertyParameter) {
-	cal.setProperty(PropertyRefreshInterval, s, params...)
+	cal.setProperty(PropertyRefreshInterval, s, append([]PropertyParameter{WithValue(string(ValueDataTypeDuration))}, params...)...)
+}
+
+// SetRefreshIntervalDuration sets REFRESH-INTERVAL, the suggested minimum time between polls of the calendar, formatted
+// as a DURATION. The interval must be at least a second.
+func (cal *Calendar) SetRefreshIntervalDuration(d time.Duration, params ...PropertyParameter) error {
+	if d < time.Second {
+		return fmt.Errorf("refresh interval %s: must be at least a second", d)
+	}
+	cal.SetRefreshInterval(NewDuration(d).String(), params...)
+	return nil
 }
 
 func (cal *Calendar) SetCalscale(s string, params ...PropertyParameter) {
@@ -578,6 +902,231 @@ func (cal *Calendar) setProperty(property Property, value string, params ...Prop
 	cal.CalendarProperties = append(cal.CalendarProperties, r)
 }
 
+func (cal *Calendar) getProperty(property Property) *CalendarProperty {
+	for i := range cal.CalendarProperties {
+		if cal.CalendarProperties[i].IANAToken == string(property) {
+			return &cal.CalendarProperties[i]
+		}
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the calendar
+func (cal *Calendar) Clone() *Calendar {
+	r := &Calendar{}
+	if cal.CalendarProperties != nil {
+		r.CalendarProperties = make([]CalendarProperty, len(cal.CalendarProperties))
+		for i := range cal.CalendarProperties {
+			r.CalendarProperties[i] = CalendarProperty{cal.CalendarProperties[i].clone()}
+		}
+	}
+	if cal.Components != nil {
+		r.Components = make([]Component, len(cal.Components))
+		for i := range cal.Components {
+			r.Components[i] = CloneComponent(cal.Components[i])
+		}
+	}
+	return r
+}
+
+type RedactOptions struct {
+	// Summary replaces the SUMMARY of redacted events. Defaults to "Busy".
+	Summary string
+	// Classifications are the CLASS values of events to redact. Defaults to ClassificationPrivate and
+	// ClassificationConfidential.
+	Classifications []Classification
+}
+
+// redactedProperties are removed from redacted events, in addition to any properties unknown to this package which may
+// also carry details, such as vendor X- properties.
+var redactedProperties = []ComponentProperty{
+	ComponentPropertySummary, ComponentPropertyDescription, ComponentPropertyLocation, ComponentPropertyAttendee,
+	ComponentPropertyOrganizer, ComponentPropertyComment, ComponentPropertyAttach, ComponentPropertyContact,
+	ComponentPropertyUrl, ComponentPropertyGeo, ComponentPropertyCategories, ComponentPropertyResources,
+	ComponentPropertyXAltDesc,
+}
+
+// Redact returns a copy of the calendar suitable for publishing a busy only view. Events with a matching CLASS have
+// their descriptive properties, alarms and unknown properties removed and their SUMMARY replaced, keeping their times,
+// recurrence and TRANSP. The calendar itself is not modified.
+func (cal *Calendar) Redact(opts RedactOptions) *Calendar {
+	summary := opts.Summary
+	if summary == "" {
+		summary = "Busy"
+	}
+	classifications := opts.Classifications
+	if len(classifications) == 0 {
+		classifications = []Classification{ClassificationPrivate, ClassificationConfidential}
+	}
+	r := cal.Clone()
+	for _, event := range r.Events() {
+		redact := false
+		for _, c := range classifications {
+			if event.GetClass() == c {
+				redact = true
+			}
+		}
+		if !redact {
+			continue
+		}
+		for _, p := range redactedProperties {
+			event.RemoveProperty(p)
+		}
+		for _, p := range event.UnknownProperties() {
+			event.RemoveProperty(ComponentProperty(p.IANAToken))
+		}
+		event.Components = nil
+		event.SetSummary(summary)
+	}
+	return r
+}
+
+// ApplyDefaultAlarm adds a DISPLAY alarm with the TRIGGER trigger, such as "-PT15M", and DESCRIPTION description to
+// every event which has no alarms.
+func (cal *Calendar) ApplyDefaultAlarm(trigger, description string) {
+	for _, event := range cal.Events() {
+		if len(event.Alarms()) > 0 {
+			continue
+		}
+		alarm := event.AddAlarm()
+		alarm.SetAction(ActionDisplay)
+		alarm.SetTrigger(trigger)
+		alarm.SetDescription(description)
+	}
+}
+
+// shiftedProperties are the date and date-time properties moved by Calendar.Shift
+var shiftedProperties = []ComponentProperty{
+	ComponentPropertyDtStart, ComponentPropertyDtEnd, ComponentPropertyDue, ComponentPropertyRecurrenceId,
+	ComponentPropertyExdate, ComponentPropertyRdate,
+}
+
+// Shift moves every DTSTART, DTEND, DUE, RECURRENCE-ID, EXDATE and RDATE of the calendar's components by d, keeping
+// their value types and TZIDs. Dates can only be moved by whole days, so if any are present d must be a multiple of 24
+// hours, otherwise an error is returned and the calendar is left unchanged. Floating times and times with a TZID are
+// moved on the wall clock, so whole days keep their time of day across daylight saving changes, while UTC times are
+// moved by d. A TZID which is neither loadable nor defined by a VTIMEZONE fails with ErrorUnresolvableTZID. Timezone
+// definitions are not changed.
+func (cal *Calendar) Shift(d time.Duration) error {
+	type update struct {
+		property *IANAProperty
+		value    string
+	}
+	locations := map[string]*time.Location{}
+	resolve := func(tzid string) (*time.Location, error) {
+		if loc, ok := locations[tzid]; ok {
+			return loc, nil
+		}
+		loc, err := time.LoadLocation(tzid)
+		if err != nil {
+			tz := findTimezone(tzid, []*Calendar{cal})
+			if tz == nil {
+				return nil, fmt.Errorf("%w: %q has no VTIMEZONE and could not be loaded: %v", ErrorUnresolvableTZID, tzid, err)
+			}
+			if loc, err = tz.ToLocation(); err != nil {
+				return nil, fmt.Errorf("%w: %q: %v", ErrorUnresolvableTZID, tzid, err)
+			}
+		}
+		locations[tzid] = loc
+		return loc, nil
+	}
+	var updates []update
+	var walk func(components []Component) error
+	walk = func(components []Component) error {
+		for _, c := range components {
+			if _, ok := c.(*VTimezone); ok {
+				continue
+			}
+			properties := c.UnknownPropertiesIANAProperties()
+			for i := range properties {
+				if !isShiftedProperty(ComponentProperty(properties[i].IANAToken)) {
+					continue
+				}
+				v, err := properties[i].shifted(d, resolve)
+				if err != nil {
+					return fmt.Errorf("%s: %w", properties[i].IANAToken, err)
+				}
+				updates = append(updates, update{property: &properties[i], value: v})
+			}
+			if err := walk(c.SubComponents()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(cal.Components); err != nil {
+		return err
+	}
+	for _, u := range updates {
+		u.property.Value = u.value
+	}
+	return nil
+}
+
+func isShiftedProperty(cp ComponentProperty) bool {
+	for _, p := range shiftedProperties {
+		if p == cp {
+			return true
+		}
+	}
+	return false
+}
+
+// shifted returns the property's value, a list of dates, date-times or periods, moved by d, with resolve giving the
+// location of its TZID
+func (p *IANAProperty) shifted(d time.Duration, resolve func(tzid string) (*time.Location, error)) (string, error) {
+	loc := time.UTC
+	if tzid, ok := p.ICalParameters[string(ParameterTzid)]; ok && len(tzid) > 0 {
+		var err error
+		if loc, err = resolve(tzid[0]); err != nil {
+			return "", err
+		}
+	}
+	values := strings.Split(p.Value, ",")
+	for i, v := range values {
+		parts := strings.Split(v, "/")
+		for j, part := range parts {
+			if j > 0 && (strings.HasPrefix(part, "P") || strings.HasPrefix(part, "+") || strings.HasPrefix(part, "-")) {
+				// A period's duration is unchanged
+				continue
+			}
+			var err error
+			parts[j], err = shiftTimeValue(part, loc, d)
+			if err != nil {
+				return "", err
+			}
+		}
+		values[i] = strings.Join(parts, "/")
+	}
+	return strings.Join(values, ","), nil
+}
+
+func shiftTimeValue(v string, loc *time.Location, d time.Duration) (string, error) {
+	if len(v) == len(icalDateFormatLocal) {
+		t, err := time.Parse(icalDateFormatLocal, v)
+		if err != nil {
+			return "", err
+		}
+		if d%(24*time.Hour) != 0 {
+			return "", fmt.Errorf("date %s can only be shifted by whole days, not %s", v, d)
+		}
+		return t.AddDate(0, 0, int(d/(24*time.Hour))).Format(icalDateFormatLocal), nil
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse(icalTimestampFormatUtc, v)
+		if err != nil {
+			return "", err
+		}
+		return t.Add(d).Format(icalTimestampFormatUtc), nil
+	}
+	t, err := time.ParseInLocation(icalTimestampFormatLocal, v, loc)
+	if err != nil {
+		return "", err
+	}
+	days := d / (24 * time.Hour)
+	return t.AddDate(0, 0, int(days)).Add(d - days*24*time.Hour).In(loc).Format(icalTimestampFormatLocal), nil
+}
+
 func (calendar *Calendar) AddEvent(id string) *VEvent {
 	e := NewEvent(id)
 	calendar.Components = append(calendar.Components, e)
@@ -588,6 +1137,131 @@ func (calendar *Calendar) AddVEvent(e *VEvent) {
 	calendar.Components = append(calendar.Components, e)
 }
 
+// AddEventUnique appends e unless an event with the same UID and RECURRENCE-ID is already present. Returns true if the
+// event was added.
+func (calendar *Calendar) AddEventUnique(e *VEvent) bool {
+	if calendar.eventIndex(e) >= 0 {
+		return false
+	}
+	calendar.Components = append(calendar.Components, e)
+	return true
+}
+
+// ComponentAt returns the calendar's i'th top level component, or nil if there is none.
+func (calendar *Calendar) ComponentAt(i int) Component {
+	if i < 0 || i >= len(calendar.Components) {
+		return nil
+	}
+	return calendar.Components[i]
+}
+
+// ReplaceComponentAt replaces the calendar's i'th top level component with c, returning ErrorComponentIndexOutOfRange
+// if there is none.
+func (calendar *Calendar) ReplaceComponentAt(i int, c Component) error {
+	if i < 0 || i >= len(calendar.Components) {
+		return fmt.Errorf("%w: %d of %d", ErrorComponentIndexOutOfRange, i, len(calendar.Components))
+	}
+	if c == nil {
+		return errors.New("replacement component is nil")
+	}
+	calendar.Components[i] = c
+	return nil
+}
+
+// UpsertEvent replaces the event with the same UID and RECURRENCE-ID as e, keeping its position, or appends e if there is
+// none. Returns true if an event was replaced.
+func (calendar *Calendar) UpsertEvent(e *VEvent) bool {
+	if i := calendar.eventIndex(e); i >= 0 {
+		calendar.Components[i] = e
+		return true
+	}
+	calendar.Components = append(calendar.Components, e)
+	return false
+}
+
+// eventIndex returns the index in Components of the event matching the UID and RECURRENCE-ID of e, or -1
+func (calendar *Calendar) eventIndex(e *VEvent) int {
+	recurrenceId := e.recurrenceIdValue()
+	for i := range calendar.Components {
+		switch event := calendar.Components[i].(type) {
+		case *VEvent:
+			if event.Id() == e.Id() && event.recurrenceIdValue() == recurrenceId {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// UnknownProperties returns every property in the calendar, its components and their sub components whose token is
+// not one of the Property constants. See ComponentBase.UnknownProperties
+func (calendar *Calendar) UnknownProperties() []IANAProperty {
+	var r []IANAProperty
+	for _, p := range calendar.CalendarProperties {
+		if !Property(p.IANAToken).IsKnown() {
+			r = append(r, IANAProperty{p.BaseProperty})
+		}
+	}
+	var walk func(components []Component)
+	walk = func(components []Component) {
+		for _, c := range components {
+			r = append(r, unknownProperties(c.UnknownPropertiesIANAProperties())...)
+			walk(c.SubComponents())
+		}
+	}
+	walk(calendar.Components)
+	return r
+}
+
+// ReferencedTZIDs returns each distinct TZID parameter used by properties of the calendar's components and their sub
+// components, in the order first seen.
+func (calendar *Calendar) ReferencedTZIDs() []string {
+	var r []string
+	seen := map[string]bool{}
+	var walk func(components []Component)
+	walk = func(components []Component) {
+		for _, c := range components {
+			for _, p := range c.UnknownPropertiesIANAProperties() {
+				for _, tzid := range p.ICalParameters[string(ParameterTzid)] {
+					if !seen[tzid] {
+						seen[tzid] = true
+						r = append(r, tzid)
+					}
+				}
+			}
+			walk(c.SubComponents())
+		}
+	}
+	walk(calendar.Components)
+	return r
+}
+
+// ComponentByUID returns the first event, todo, journal or free/busy component with the given UID, or nil if there is
+// none.
+func (calendar *Calendar) ComponentByUID(uid string) Component {
+	for _, c := range calendar.Components {
+		switch c := c.(type) {
+		case *VEvent:
+			if c.Id() == uid {
+				return c
+			}
+		case *VTodo:
+			if c.Id() == uid {
+				return c
+			}
+		case *VJournal:
+			if c.Id() == uid {
+				return c
+			}
+		case *VBusy:
+			if c.Id() == uid {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
 func (calendar *Calendar) Events() (r []*VEvent) {
 	r = []*VEvent{}
 	for i := range calendar.Components {
@@ -599,6 +1273,84 @@ func (calendar *Calendar) Events() (r []*VEvent) {
 	return
 }
 
+// CalendarStats summarises the contents of a calendar, see Calendar.Stats
+type CalendarStats struct {
+	Events          int
+	Todos           int
+	Journals        int
+	RecurringEvents int
+	AllDayEvents    int
+	Timezones       int
+	// Earliest is the earliest event DTSTART and Latest the latest event end, or zero if no event has a parseable
+	// DTSTART. Recurrences are not expanded, so Latest is the end of the first instance of a recurring event.
+	Earliest time.Time
+	Latest   time.Time
+	// UnparseableEvents counts the events left out of Earliest and Latest as their DTSTART could not be parsed.
+	UnparseableEvents int
+}
+
+// Stats returns counts of the calendar's components and the date range its events cover, useful for logging and for
+// characterising an imported feed. An event whose end cannot be determined contributes its start to Latest.
+func (cal *Calendar) Stats() CalendarStats {
+	var r CalendarStats
+	for _, c := range cal.Components {
+		switch c := c.(type) {
+		case *VEvent:
+			r.Events++
+			if c.RecurrenceKind() == RecurrenceKindMaster {
+				r.RecurringEvents++
+			}
+			if c.isAllDay() {
+				r.AllDayEvents++
+			}
+			start, err := c.GetStartAt()
+			if err != nil {
+				r.UnparseableEvents++
+				continue
+			}
+			end, err := c.EffectiveEnd()
+			if err != nil || end.Before(start) {
+				end = start
+			}
+			if r.Earliest.IsZero() || start.Before(r.Earliest) {
+				r.Earliest = start
+			}
+			if end.After(r.Latest) {
+				r.Latest = end
+			}
+		case *VTodo:
+			r.Todos++
+		case *VJournal:
+			r.Journals++
+		case *VTimezone:
+			r.Timezones++
+		}
+	}
+	return r
+}
+
+// FindEventsBySummary returns the events whose SUMMARY contains substr, matched against the unescaped text and
+// optionally ignoring case. Events without a SUMMARY never match, even an empty substr.
+func (cal *Calendar) FindEventsBySummary(substr string, caseInsensitive bool) []*VEvent {
+	if caseInsensitive {
+		substr = strings.ToLower(substr)
+	}
+	var r []*VEvent
+	for _, event := range cal.Events() {
+		summary, ok := event.LookupValue(ComponentPropertySummary)
+		if !ok {
+			continue
+		}
+		if caseInsensitive {
+			summary = strings.ToLower(summary)
+		}
+		if strings.Contains(summary, substr) {
+			r = append(r, event)
+		}
+	}
+	return r
+}
+
 func (calendar *Calendar) RemoveEvent(id string) {
 	for i := range calendar.Components {
 		switch event := calendar.Components[i].(type) {
@@ -627,6 +1379,7 @@ func ParseCalendarFromUrl(url string, opts ...any) (*Calendar, error) {
 	var ctx context.Context
 	var req *http.Request
 	var client HttpClientLike = http.DefaultClient
+	var parseOps []any
 	for opti, opt := range opts {
 		switch opt := opt.(type) {
 		case *http.Client:
@@ -644,7 +1397,10 @@ func ParseCalendarFromUrl(url string, opts ...any) (*Calendar, error) {
 		case func() context.Context:
 			ctx = opt()
 		default:
-			return nil, fmt.Errorf("unknown optional argument %d on ParseCalendarFromUrl: %s", opti, reflect.TypeOf(opt))
+			if _, err := parseParseOps([]any{opt}); err != nil {
+				return nil, fmt.Errorf("unknown optional argument %d on ParseCalendarFromUrl: %s", opti, reflect.TypeOf(opt))
+			}
+			parseOps = append(parseOps, opt)
 		}
 	}
 	if ctx == nil {
@@ -657,14 +1413,14 @@ func ParseCalendarFromUrl(url string, opts ...any) (*Calendar, error) {
 			return nil, fmt.Errorf("creating http request: %w", err)
 		}
 	}
-	return parseCalendarFromHttpRequest(client, req)
+	return parseCalendarFromHttpRequest(client, req, parseOps...)
 }
 
 type HttpClientLike interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-func parseCalendarFromHttpRequest(client HttpClientLike, request *http.Request) (*Calendar, error) {
+func parseCalendarFromHttpRequest(client HttpClientLike, request *http.Request, parseOps ...any) (*Calendar, error) {
 	resp, err := client.Do(request)
 	if err != nil {
 		return nil, fmt.Errorf("http request: %w", err)
@@ -675,15 +1431,114 @@ func parseCalendarFromHttpRequest(client HttpClientLike, request *http.Request)
 		}
 	}(resp.Body)
 	var cal *Calendar
-	cal, err = ParseCalendar(resp.Body)
+	cal, err = ParseCalendar(resp.Body, parseOps...)
 	// This allows the defer func to change the error
 	return cal, err
 }
 
-func ParseCalendar(r io.Reader) (*Calendar, error) {
+// WithDropUnknownComponents see ParseConfiguration.DropUnknownComponents
+type WithDropUnknownComponents bool
+
+// WithDropUnknownProperties see ParseConfiguration.DropUnknownProperties
+type WithDropUnknownProperties bool
+
+// WithStrict see ParseConfiguration.Strict
+type WithStrict bool
+
+// WithLatin1Fallback see ParseConfiguration.Latin1Fallback
+type WithLatin1Fallback bool
+
+// WithPreserveRawValues see ParseConfiguration.PreserveRawValues
+type WithPreserveRawValues bool
+
+// WithLenient see ParseConfiguration.Lenient
+type WithLenient bool
+
+// WithValidate see ParseConfiguration.Validate
+type WithValidate bool
+
+// WithTZIDFallback see ParseConfiguration.TZIDFallback
+type WithTZIDFallback TZIDFallback
+
+// TZIDFallback configures how times with a TZID which time.LoadLocation fails on, such as the Windows zone names in
+// Exchange exports, are rewritten while parsing so the time getters can read them. TZIDs defined by a VTIMEZONE in the
+// calendar are left alone.
+type TZIDFallback struct {
+	// UTC rewrites the times as UTC, rather than as floating times read in the local time zone.
+	UTC bool
+	// Warn, if not nil, is called for each property rewritten with a *TimeParseError wrapping ErrorUnresolvableTZID.
+	Warn func(err error)
+}
+
+type ParseConfiguration struct {
+	// DropUnknownComponents discards components which would otherwise be kept as a GeneralComponent, such as vendor
+	// X- components.
+	DropUnknownComponents bool
+	// DropUnknownProperties discards properties which are not one of the Property constants.
+	DropUnknownProperties bool
+	// Strict fails parsing with ErrorUnknownProperty or ErrorUnknownComponent on any property or component which is not
+	// defined by the RFCs, as given by the Property and ComponentType constants, unless it is an X- extension or a
+	// property registered with RegisterPropertyValueType.
+	Strict bool
+	// Latin1Fallback decodes bytes which are not valid UTF-8 as Windows-1252, a superset of ISO-8859-1, as commonly
+	// found in hand edited feeds containing smart quotes and dashes. Valid UTF-8 is unaffected.
+	Latin1Fallback bool
+	// PreserveRawValues keeps each property value exactly as it appeared in the input and serializes it unchanged,
+	// rather than re-escaping it, for as long as the value is not modified. This suits feeds which are passed through
+	// and must not be altered, for example because they are signed.
+	PreserveRawValues bool
+	// Lenient applies heuristic repairs to malformed input. Currently a line which cannot be the start of a property,
+	// as it has no name followed by ; or :, is joined to the previous line as a fold which lost its leading space.
+	Lenient bool
+	// Validate runs Calendar.Validate on the parsed calendar, failing the parse with the violations found, for
+	// rejecting non-conformant input at the door.
+	Validate bool
+	// TZIDFallback, if not nil, removes each TZID which can not be loaded from the properties using it, leaving their
+	// times floating or, with TZIDFallback.UTC, in UTC. Otherwise the time getters fail on such properties.
+	TZIDFallback *TZIDFallback
+}
+
+func parseParseOps(ops []any) (*ParseConfiguration, error) {
+	parseConfig := &ParseConfiguration{}
+	for opi, op := range ops {
+		switch op := op.(type) {
+		case WithDropUnknownComponents:
+			parseConfig.DropUnknownComponents = bool(op)
+		case WithDropUnknownProperties:
+			parseConfig.DropUnknownProperties = bool(op)
+		case WithStrict:
+			parseConfig.Strict = bool(op)
+		case WithLatin1Fallback:
+			parseConfig.Latin1Fallback = bool(op)
+		case WithPreserveRawValues:
+			parseConfig.PreserveRawValues = bool(op)
+		case WithLenient:
+			parseConfig.Lenient = bool(op)
+		case WithValidate:
+			parseConfig.Validate = bool(op)
+		case WithTZIDFallback:
+			fallback := TZIDFallback(op)
+			parseConfig.TZIDFallback = &fallback
+		case *ParseConfiguration:
+			return op, nil
+		case error:
+			return nil, op
+		default:
+			return nil, fmt.Errorf("unknown op %d of type %s", opi, reflect.TypeOf(op))
+		}
+	}
+	return parseConfig, nil
+}
+
+func ParseCalendar(r io.Reader, ops ...any) (*Calendar, error) {
+	parseConfig, err := parseParseOps(ops)
+	if err != nil {
+		return nil, err
+	}
 	state := "begin"
 	c := &Calendar{}
 	cs := NewCalendarStream(r)
+	cs.parseConfig = parseConfig
 	cont := true
 	for ln := 0; cont; ln++ {
 		l, err := cs.ReadLine()
@@ -698,7 +1553,7 @@ func ParseCalendar(r io.Reader) (*Calendar, error) {
 		if l == nil || len(*l) == 0 {
 			continue
 		}
-		line, err := ParseProperty(*l)
+		line, err := parseProperty(*l, cs.parseConfig.PreserveRawValues)
 		if err != nil {
 			return nil, fmt.Errorf("parsing line %d: %w", ln, err)
 		}
@@ -730,7 +1585,13 @@ func ParseCalendar(r io.Reader) (*Calendar, error) {
 			case "BEGIN":
 				state = "components"
 			default: // TODO put in all the supported types for type switching etc.
-				c.CalendarProperties = append(c.CalendarProperties, CalendarProperty{*line})
+				keep, err := cs.keepProperty(line)
+				if err != nil {
+					return nil, fmt.Errorf("parsing calendar line %d: %w", ln, err)
+				}
+				if keep {
+					c.CalendarProperties = append(c.CalendarProperties, CalendarProperty{*line})
+				}
 			}
 			if state != "components" {
 				break
@@ -762,18 +1623,148 @@ func ParseCalendar(r io.Reader) (*Calendar, error) {
 			return nil, errors.New("malformed calendar; bad state")
 		}
 	}
+	if parseConfig.TZIDFallback != nil {
+		c.applyTZIDFallback(parseConfig.TZIDFallback)
+	}
+	if parseConfig.Validate {
+		if err := c.Validate(); err != nil {
+			return nil, fmt.Errorf("validating calendar: %w", err)
+		}
+	}
 	return c, nil
 }
 
+// applyTZIDFallback rewrites the properties with a TZID which can neither be loaded nor is defined by one of the
+// calendar's VTIMEZONEs as floating or UTC times
+func (calendar *Calendar) applyTZIDFallback(fallback *TZIDFallback) {
+	loadErrs := map[string]error{}
+	var walk func(components []Component)
+	walk = func(components []Component) {
+		for _, c := range components {
+			properties := c.UnknownPropertiesIANAProperties()
+			for i := range properties {
+				p := &properties[i]
+				tzid, err := p.parameterValue(ParameterTzid)
+				if err != nil {
+					continue
+				}
+				loadErr, ok := loadErrs[tzid]
+				if !ok {
+					_, loadErr = time.LoadLocation(tzid)
+					if loadErr != nil && findTimezone(tzid, []*Calendar{calendar}) != nil {
+						loadErr = nil
+					}
+					loadErrs[tzid] = loadErr
+				}
+				if loadErr == nil {
+					continue
+				}
+				reason := fmt.Sprintf("TZID %q removed, reading the time as floating", tzid)
+				delete(p.ICalParameters, string(ParameterTzid))
+				if fallback.UTC {
+					reason = fmt.Sprintf("TZID %q removed, reading the time as UTC", tzid)
+					p.Value = utcTimeValues(p.Value)
+				}
+				if fallback.Warn != nil {
+					fallback.Warn(&TimeParseError{
+						Property: ComponentProperty(p.IANAToken),
+						Value:    p.Value,
+						Reason:   reason,
+						Err:      fmt.Errorf("%w: %v", ErrorUnresolvableTZID, loadErr),
+					})
+				}
+			}
+			walk(c.SubComponents())
+		}
+	}
+	walk(calendar.Components)
+}
+
+var localTimestamp = regexp.MustCompile("^[0-9]{8}T[0-9]{6}$")
+
+// utcTimeValues marks the local date-times of a comma separated list of times or periods as UTC
+func utcTimeValues(s string) string {
+	values := strings.Split(s, ",")
+	for i, v := range values {
+		parts := strings.Split(v, "/")
+		for j, part := range parts {
+			if localTimestamp.MatchString(part) {
+				parts[j] = part + "Z"
+			}
+		}
+		values[i] = strings.Join(parts, "/")
+	}
+	return strings.Join(values, ",")
+}
+
+// RoundTrip parses ics and serializes the result, for checking a calendar survives being read and written. The output
+// uses CRLF line endings if ics contains any, otherwise LF, so unchanged input compares equal.
+func RoundTrip(ics string) (string, error) {
+	cal, err := ParseCalendar(strings.NewReader(ics))
+	if err != nil {
+		return "", err
+	}
+	newLine := WithNewLineUnix
+	if strings.Contains(ics, "\r\n") {
+		newLine = WithNewLineWindows
+	}
+	return cal.Serialize(newLine), nil
+}
+
 type CalendarStream struct {
-	r io.Reader
-	b *bufio.Reader
+	r           io.Reader
+	b           *bufio.Reader
+	parseConfig *ParseConfiguration
 }
 
 func NewCalendarStream(r io.Reader) *CalendarStream {
 	return &CalendarStream{
-		r: r,
-		b: bufio.NewReader(r),
+		r:           r,
+		b:           bufio.NewReader(r),
+		parseConfig: &ParseConfiguration{},
+	}
+}
+
+// keepProperty returns if a parsed property should be kept, or an error if it is unknown when parsing strictly
+func (cs *CalendarStream) keepProperty(line *BaseProperty) (bool, error) {
+	known := Property(line.IANAToken).IsKnown()
+	if cs.parseConfig.Strict && !known && !isExtensionName(line.IANAToken) {
+		if _, ok := registeredPropertyValueType(line.IANAToken); !ok {
+			return false, fmt.Errorf("%w: %s", ErrorUnknownProperty, line.IANAToken)
+		}
+	}
+	return !cs.parseConfig.DropUnknownProperties || known, nil
+}
+
+// isExtensionName returns true for experimental X- names
+func isExtensionName(name string) bool {
+	return len(name) > 2 && strings.EqualFold(name[:2], "X-")
+}
+
+// maxPropertyNamePeek is how far ahead unfoldedContinuation looks for the end of a property name
+const maxPropertyNamePeek = 256
+
+// unfoldedContinuation returns true if the next line cannot start a property, so is most likely the continuation of a
+// folded line which is missing its leading space. Blank lines are not continuations.
+func (cs *CalendarStream) unfoldedContinuation() bool {
+	for n := 16; ; n *= 2 {
+		p, err := cs.b.Peek(n)
+		for i, c := range p {
+			switch {
+			case c == ';' || c == ':':
+				return i == 0
+			case c == '\r' || c == '\n':
+				return i > 0
+			case c != '-' && !('A' <= c && c <= 'Z') && !('a' <= c && c <= 'z') && !('0' <= c && c <= '9'):
+				return true
+			}
+		}
+		if err != nil {
+			return len(p) > 0
+		}
+		if n >= maxPropertyNamePeek {
+			return false
+		}
 	}
 }
 
@@ -806,6 +1797,8 @@ func (cs *CalendarStream) ReadLine() (*ContentLine, error) {
 				c = false
 			case p[0] == ' ' || p[0] == '\t':
 				_, _ = cs.b.Discard(1) // nolint:errcheck
+			case cs.parseConfig.Lenient && cs.unfoldedContinuation():
+				// Joined as is, there is no leading space to discard
 			default:
 				c = false
 			}
@@ -826,6 +1819,9 @@ func (cs *CalendarStream) ReadLine() (*ContentLine, error) {
 	if len(r) == 0 && err != nil {
 		return nil, err
 	}
+	if cs.parseConfig.Latin1Fallback {
+		r = fixWindows1252(r)
+	}
 	cl := ContentLine(r)
 	return &cl, err
 }
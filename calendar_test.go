@@ -2,6 +2,7 @@ package ics
 
 import (
 	"bytes"
+	"context"
 	"embed"
 	_ "embed"
 	"github.com/google/go-cmp/cmp"
@@ -16,6 +17,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -119,6 +121,111 @@ func TestTimeParsing(t *testing.T) {
 	}
 }
 
+func TestAllDayDefaultEnd(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+PRODID:-//Google Inc//Google Calendar 70.9054//EN
+VERSION:2.0
+CALSCALE:GREGORIAN
+METHOD:PUBLISH
+X-WR-CALNAME:Holidays
+X-WR-TIMEZONE:Europe/London
+BEGIN:VEVENT
+DTSTART;VALUE=DATE:20241225
+DTSTAMP:20241201T120000Z
+UID:20241225_christmas@google.com
+CLASS:PUBLIC
+CREATED:20240101T000000Z
+LAST-MODIFIED:20240101T000000Z
+SEQUENCE:0
+STATUS:CONFIRMED
+SUMMARY:Christmas Day
+TRANSP:TRANSPARENT
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	event := cal.Events()[0]
+
+	end, err := event.GetAllDayEndAt()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 12, 26, 0, 0, 0, 0, time.Local), end)
+	end, err = event.EffectiveEnd()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 12, 26, 0, 0, 0, 0, time.Local), end)
+	_, err = event.GetEndAt()
+	assert.ErrorIs(t, err, ErrorPropertyNotFound)
+
+	event.SetProperty(ComponentPropertyDuration, "P2D")
+	_, err = event.GetAllDayEndAt()
+	assert.ErrorIs(t, err, ErrorPropertyNotFound)
+}
+
+func TestParseWithTZIDFallback(t *testing.T) {
+	ics := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART;TZID=W. Europe Standard Time:20240301T090000
+DTEND;TZID=Europe/Berlin:20240301T100000
+EXDATE;TZID=W. Europe Standard Time:20240308T090000,20240315T090000
+END:VEVENT
+END:VCALENDAR
+`
+	cal, err := ParseCalendar(strings.NewReader(ics))
+	require.NoError(t, err)
+	_, err = cal.Events()[0].GetStartAt()
+	assert.Error(t, err)
+
+	var warnings []error
+	cal, err = ParseCalendar(strings.NewReader(ics), WithTZIDFallback{UTC: true, Warn: func(err error) { warnings = append(warnings, err) }})
+	require.NoError(t, err)
+	event := cal.Events()[0]
+	start, err := event.GetStartAt()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, []string{"20240308T090000Z,20240315T090000Z"}, event.Exdates())
+	assert.Equal(t, []string{"Europe/Berlin"}, event.GetProperty(ComponentPropertyDtEnd).ICalParameters[string(ParameterTzid)])
+	require.Len(t, warnings, 2)
+	var timeErr *TimeParseError
+	require.ErrorAs(t, warnings[0], &timeErr)
+	assert.Equal(t, ComponentPropertyDtStart, timeErr.Property)
+	assert.ErrorIs(t, warnings[0], ErrorUnresolvableTZID)
+
+	cal, err = ParseCalendar(strings.NewReader(ics), WithTZIDFallback{})
+	require.NoError(t, err)
+	start, err = cal.Events()[0].GetStartAt()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local), start)
+}
+
+func TestParseWithTZIDFallbackKeepsEmbeddedTimezones(t *testing.T) {
+	ics := `BEGIN:VCALENDAR
+BEGIN:VTIMEZONE
+TZID:Custom Zone
+BEGIN:STANDARD
+DTSTART:19700101T000000
+TZOFFSETFROM:+0500
+TZOFFSETTO:+0500
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:a
+DTSTART;TZID=Custom Zone:20240301T090000
+END:VEVENT
+END:VCALENDAR
+`
+	var warnings []error
+	cal, err := ParseCalendar(strings.NewReader(ics), WithTZIDFallback{UTC: true, Warn: func(err error) { warnings = append(warnings, err) }})
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	start := cal.Events()[0].GetProperty(ComponentPropertyDtStart)
+	assert.Equal(t, "20240301T090000", start.Value)
+	assert.Equal(t, []string{"Custom Zone"}, start.ICalParameters[string(ParameterTzid)])
+
+	loc, err := cal.Timezones()[0].ToLocation()
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 1, 4, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 9, 0, 0, 0, loc).UTC())
+}
+
 func TestCalendarStream(t *testing.T) {
 	i := `
 ATTENDEE;RSVP=TRUE;ROLE=REQ-PARTICIPANT;CUTYPE=GROUP:
@@ -493,3 +600,753 @@ func TestIssue77(t *testing.T) {
 		t.Fatalf("Error reading file: %s", err)
 	}
 }
+
+func TestNewMeeting(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	clock := WithClock(func() time.Time { return time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC) })
+	cal, err := NewMeeting("meeting-1", "Planning", start, start.Add(time.Hour), "boss@example.com", []string{"a@example.com", "mailto:b@example.com"}, clock)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, len(cal.Events()))
+	event := cal.Events()[0]
+	assert.Equal(t, "meeting-1", event.Id())
+	assert.Equal(t, "20240201T120000Z", event.GetProperty(ComponentPropertyDtstamp).Value)
+	assert.Equal(t, "mailto:boss@example.com", event.GetProperty(ComponentPropertyOrganizer).Value)
+	attendees := event.Attendees()
+	if assert.Len(t, attendees, 2) {
+		assert.Equal(t, "a@example.com", attendees[0].Email())
+		assert.Equal(t, "b@example.com", attendees[1].Email())
+		assert.Equal(t, ParticipationStatusNeedsAction, attendees[0].ParticipationStatus())
+		assert.Equal(t, []string{"true"}, attendees[0].ICalParameters[string(ParameterRsvp)])
+	}
+	got, err := event.GetStartAt()
+	assert.NoError(t, err)
+	assert.True(t, start.Equal(got))
+
+	text := cal.Serialize()
+	assert.Contains(t, text, "METHOD:REQUEST")
+	assert.Contains(t, text, "DTEND:20240301T100000Z")
+}
+
+func TestNewFreeBusyRequest(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	random := WithRandomSource{bytes.NewReader(bytes.Repeat([]byte{0xff}, 16))}
+	clock := WithClock(func() time.Time { return time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC) })
+	cal, err := NewFreeBusyRequest("boss@example.com", "a@example.com", from, from.AddDate(0, 0, 7), random, clock)
+	require.NoError(t, err)
+
+	assert.Equal(t, MethodRequest, cal.GetMethod())
+	busys := cal.Busys()
+	require.Len(t, busys, 1)
+	busy := busys[0]
+	assert.Equal(t, "ffffffff-ffff-4fff-bfff-ffffffffffff", busy.Id())
+	assert.Equal(t, "20240201T120000Z", busy.GetProperty(ComponentPropertyDtstamp).Value)
+	assert.Equal(t, "mailto:boss@example.com", busy.GetProperty(ComponentPropertyOrganizer).Value)
+	assert.Equal(t, "mailto:a@example.com", busy.GetProperty(ComponentPropertyAttendee).Value)
+
+	text := cal.Serialize()
+	assert.Contains(t, text, "DTSTART:20240301T000000Z\n")
+	assert.Contains(t, text, "DTEND:20240308T000000Z\n")
+
+	_, err = NewFreeBusyRequest("boss@example.com", "a@example.com", from, from.AddDate(0, 0, 7), WithRandomSource{strings.NewReader("short")})
+	assert.Error(t, err)
+}
+
+func TestAddEventUnique(t *testing.T) {
+	cal := NewCalendar()
+	assert.True(t, cal.AddEventUnique(NewEvent("uid-1")))
+	assert.False(t, cal.AddEventUnique(NewEvent("uid-1")))
+
+	override := NewEvent("uid-1")
+	override.SetProperty(ComponentPropertyRecurrenceId, "20240301T090000Z")
+	assert.True(t, cal.AddEventUnique(override))
+
+	duplicateOverride := NewEvent("uid-1")
+	duplicateOverride.SetProperty(ComponentPropertyRecurrenceId, "20240301T090000Z")
+	assert.False(t, cal.AddEventUnique(duplicateOverride))
+
+	assert.True(t, cal.AddEventUnique(NewEvent("uid-2")))
+	assert.Len(t, cal.Events(), 3)
+}
+
+func TestSerializeForceUTC(t *testing.T) {
+	cal := NewCalendar()
+	e := cal.AddEvent("test-force-utc")
+	e.SetProperty(ComponentPropertyDtStart, "20240301T100000", WithTZID("Europe/Copenhagen"))
+	e.SetProperty(ComponentPropertyExdate, "20240308T100000,20240315T100000", WithTZID("Europe/Copenhagen"))
+	e.SetAllDayEndAt(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC))
+
+	text := cal.Serialize(WithForceUTC(true))
+	assert.Contains(t, text, "DTSTART:20240301T090000Z")
+	assert.Contains(t, text, "EXDATE:20240308T090000Z,20240315T090000Z")
+	assert.Contains(t, text, "DTEND;VALUE=DATE:20240302")
+
+	assert.Contains(t, cal.Serialize(), "DTSTART;TZID=Europe/Copenhagen:20240301T100000")
+
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VTIMEZONE
+TZID:Custom Zone
+BEGIN:STANDARD
+DTSTART:19700101T000000
+TZOFFSETFROM:+0500
+TZOFFSETTO:+0500
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:custom
+DTSTART;TZID=Custom Zone:20240301T090000
+DTEND;TZID=Nowhere/Unknown:20240301T100000
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	text = cal.Serialize(WithForceUTC(true))
+	assert.Contains(t, text, "DTSTART:20240301T040000Z")
+	assert.Contains(t, text, "DTEND;TZID=Nowhere/Unknown:20240301T100000")
+}
+
+func TestSerializeToContext(t *testing.T) {
+	cal := NewCalendar()
+	cal.AddEvent("test-context")
+
+	b := &bytes.Buffer{}
+	assert.NoError(t, cal.SerializeToContext(context.Background(), b))
+	assert.Equal(t, cal.Serialize(), b.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.Reset()
+	err := cal.SerializeToContext(ctx, b)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotContains(t, b.String(), "BEGIN:VEVENT")
+}
+
+func TestParseCalendarDropUnknown(t *testing.T) {
+	input := `BEGIN:VCALENDAR
+VERSION:2.0
+X-CUSTOM-FIELD:test
+PRODID:-//arran4//Golang ICS Library
+BEGIN:VEVENT
+UID:test-drop-unknown
+X-VENDOR-FIELD:secret
+SUMMARY:Summary
+BEGIN:X-VENDOR-COMPONENT
+X-DATA:secret
+END:X-VENDOR-COMPONENT
+END:VEVENT
+BEGIN:X-VENDOR-COMPONENT
+X-DATA:secret
+END:X-VENDOR-COMPONENT
+END:VCALENDAR
+`
+	testCases := []struct {
+		name   string
+		ops    []any
+		output string
+	}{
+		{
+			name:   "default keeps everything",
+			output: input,
+		},
+		{
+			name: "drop unknown components",
+			ops:  []any{WithDropUnknownComponents(true)},
+			output: `BEGIN:VCALENDAR
+VERSION:2.0
+X-CUSTOM-FIELD:test
+PRODID:-//arran4//Golang ICS Library
+BEGIN:VEVENT
+UID:test-drop-unknown
+X-VENDOR-FIELD:secret
+SUMMARY:Summary
+END:VEVENT
+END:VCALENDAR
+`,
+		},
+		{
+			name: "drop unknown properties",
+			ops:  []any{WithDropUnknownProperties(true)},
+			output: `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//arran4//Golang ICS Library
+BEGIN:VEVENT
+UID:test-drop-unknown
+SUMMARY:Summary
+BEGIN:X-VENDOR-COMPONENT
+END:X-VENDOR-COMPONENT
+END:VEVENT
+BEGIN:X-VENDOR-COMPONENT
+END:X-VENDOR-COMPONENT
+END:VCALENDAR
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := ParseCalendar(strings.NewReader(input), tc.ops...)
+			if !assert.NoError(t, err) {
+				return
+			}
+			text := strings.ReplaceAll(c.Serialize(), "\r\n", "\n")
+			assert.Equal(t, tc.output, text)
+		})
+	}
+
+	_, err := ParseCalendar(strings.NewReader(input), "bogus")
+	assert.Error(t, err)
+}
+
+func TestUnknownProperties(t *testing.T) {
+	input := `BEGIN:VCALENDAR
+VERSION:2.0
+X-CUSTOM-FIELD:test
+PRODID:-//arran4//Golang ICS Library
+BEGIN:VEVENT
+UID:test-unknown
+X-VENDOR-FIELD:secret
+SUMMARY:Summary
+BEGIN:VALARM
+ACTION:DISPLAY
+X-ALARM-FIELD:1
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`
+	c, err := ParseCalendar(strings.NewReader(input))
+	if !assert.NoError(t, err) {
+		return
+	}
+	event := c.Events()[0]
+	if assert.Len(t, event.UnknownProperties(), 1) {
+		assert.Equal(t, "X-VENDOR-FIELD", event.UnknownProperties()[0].IANAToken)
+	}
+	var tokens []string
+	for _, p := range c.UnknownProperties() {
+		tokens = append(tokens, p.IANAToken)
+	}
+	assert.Equal(t, []string{"X-CUSTOM-FIELD", "X-VENDOR-FIELD", "X-ALARM-FIELD"}, tokens)
+}
+
+func TestReferencedTZIDs(t *testing.T) {
+	cal := NewCalendar()
+	cal.AddTimezone("Europe/Unused")
+	e := cal.AddEvent("test-tzids")
+	e.SetProperty(ComponentPropertyDtStart, "20240301T100000", WithTZID("Europe/Copenhagen"))
+	e.SetProperty(ComponentPropertyDtEnd, "20240301T110000", WithTZID("Europe/Copenhagen"))
+	e.AddExdate("20240308T100000", WithTZID("America/New_York"))
+	todo := cal.AddTodo("test-tzids-todo")
+	todo.SetProperty(ComponentPropertyDue, "20240301T100000", WithTZID("Australia/Sydney"))
+
+	assert.Equal(t, []string{"Europe/Copenhagen", "America/New_York", "Australia/Sydney"}, cal.ReferencedTZIDs())
+}
+
+func TestEstimatedSize(t *testing.T) {
+	cal := NewCalendar()
+	event := cal.AddEvent("test-estimated-size")
+	event.SetDescription(strings.Repeat("A long description. ", 20))
+	event.SetStartAt(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, len(cal.Serialize()), cal.EstimatedSize())
+	assert.Equal(t, len(cal.Serialize(WithNewLineWindows)), cal.EstimatedSize(WithNewLineWindows))
+	assert.Greater(t, cal.EstimatedSize(WithNewLineWindows), cal.EstimatedSize())
+}
+
+func TestSerializeVersionProdIdFirst(t *testing.T) {
+	cal := &Calendar{}
+	cal.SetStandardName("Team")
+	cal.SetProductId("-//Example//Test//EN")
+	cal.SetVersion("2.0")
+
+	text := cal.Serialize(WithVersionProdIdFirst(true))
+	assert.Equal(t, "BEGIN:VCALENDAR\nVERSION:2.0\nPRODID:-//Example//Test//EN\nNAME:Team\nEND:VCALENDAR\n", text)
+	assert.Equal(t, "BEGIN:VCALENDAR\nNAME:Team\nPRODID:-//Example//Test//EN\nVERSION:2.0\nEND:VCALENDAR\n", cal.Serialize())
+	assert.Equal(t, string(PropertyName), cal.CalendarProperties[0].IANAToken)
+}
+
+func TestSerializeNoTrailingNewline(t *testing.T) {
+	cal := NewCalendar()
+	assert.True(t, strings.HasSuffix(cal.Serialize(), "END:VCALENDAR"+string(NewLine)))
+	assert.True(t, strings.HasSuffix(cal.Serialize(WithNoTrailingNewline(true)), "\nEND:VCALENDAR"))
+}
+
+func TestComponentByUID(t *testing.T) {
+	cal := NewCalendar()
+	cal.AddEvent("event")
+	todo := cal.AddTodo("todo")
+	journal := cal.AddJournal("journal")
+	busy := cal.AddBusy("busy")
+
+	assert.Equal(t, todo, cal.ComponentByUID("todo"))
+	assert.Equal(t, journal, cal.ComponentByUID("journal"))
+	assert.Equal(t, busy, cal.ComponentByUID("busy"))
+	assert.Equal(t, ComponentVEvent, cal.ComponentByUID("event").ComponentType())
+	assert.Nil(t, cal.ComponentByUID("missing"))
+}
+
+func TestClone(t *testing.T) {
+	cal := NewCalendar()
+	e := cal.AddEvent("test-clone")
+	e.SetSummary("Original", WithCN("cn"))
+	e.AddAlarm().SetAction(ActionDisplay)
+
+	clone := cal.Clone()
+	assert.Equal(t, cal.Serialize(), clone.Serialize())
+
+	clonedEvent := clone.Events()[0]
+	clonedEvent.SetSummary("Changed")
+	clonedEvent.Alarms()[0].SetAction(ActionAudio)
+	cal.CalendarProperties[0].ICalParameters["X"] = []string{"y"}
+	assert.Equal(t, "Original", e.GetProperty(ComponentPropertySummary).Value)
+	assert.Equal(t, string(ActionDisplay), e.Alarms()[0].GetProperty(ComponentPropertyAction).Value)
+	assert.NotContains(t, clone.Serialize(), "X=y")
+}
+
+func TestRedact(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	cal := NewCalendar()
+	private := cal.AddEvent("private")
+	private.SetClass(ClassificationPrivate)
+	private.SetStartAt(start)
+	private.SetEndAt(start.Add(time.Hour))
+	private.SetSummary("Doctor")
+	private.SetDescription("Details")
+	private.SetLocation("Clinic")
+	private.AddAttendee("doctor@example.com")
+	private.AddProperty("X-ALT-DESC", "<b>Details</b>", WithFmtType("text/html"))
+	private.SetTimeTransparency(TransparencyOpaque)
+	private.AddAlarm().SetTrigger("-PT15M")
+	public := cal.AddEvent("public")
+	public.SetSummary("Standup")
+
+	redacted := cal.Redact(RedactOptions{})
+
+	text := strings.ReplaceAll(redacted.Events()[0].Serialize(defaultSerializationOptions()), "\r\n", "\n")
+	assert.Equal(t, `BEGIN:VEVENT
+UID:private
+CLASS:PRIVATE
+DTSTART:20240301T090000Z
+DTEND:20240301T100000Z
+TRANSP:OPAQUE
+SUMMARY:Busy
+END:VEVENT
+`, text)
+	assert.Equal(t, "Standup", redacted.Events()[1].GetProperty(ComponentPropertySummary).Value)
+	assert.Equal(t, "Doctor", private.GetProperty(ComponentPropertySummary).Value)
+
+	redacted = cal.Redact(RedactOptions{Summary: "Away", Classifications: []Classification{ClassificationPublic}})
+	assert.Equal(t, "Doctor", redacted.Events()[0].GetProperty(ComponentPropertySummary).Value)
+	assert.Equal(t, "Away", redacted.Events()[1].GetProperty(ComponentPropertySummary).Value)
+}
+
+func TestCalendarShift(t *testing.T) {
+	input := `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VTIMEZONE
+TZID:Europe/Berlin
+BEGIN:STANDARD
+DTSTART:19701025T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:a
+DTSTART;TZID=Europe/Berlin:20240330T120000
+DTEND:20240330T120000Z
+RRULE:FREQ=DAILY;COUNT=3
+EXDATE;TZID=Europe/Berlin:20240331T120000,20240401T120000
+RDATE;VALUE=PERIOD:20240405T100000Z/PT1H
+END:VEVENT
+BEGIN:VTODO
+UID:b
+DUE:20240330T120000
+END:VTODO
+END:VCALENDAR
+`
+	cal, err := ParseCalendar(strings.NewReader(input))
+	require.NoError(t, err)
+	require.NoError(t, cal.Shift(24*time.Hour))
+	event := cal.Events()[0]
+	assert.Equal(t, "20240331T120000", event.GetProperty(ComponentPropertyDtStart).Value)
+	assert.Equal(t, "20240331T120000Z", event.GetProperty(ComponentPropertyDtEnd).Value)
+	assert.Equal(t, "20240401T120000,20240402T120000", event.GetProperty(ComponentPropertyExdate).Value)
+	assert.Equal(t, "20240406T100000Z/PT1H", event.GetProperty(ComponentPropertyRdate).Value)
+	assert.Equal(t, "20240331T120000", cal.Todos()[0].GetProperty(ComponentPropertyDue).Value)
+	assert.Equal(t, "19701025T030000", cal.Timezones()[0].Components[0].UnknownPropertiesIANAProperties()[0].Value)
+
+	allDay, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nDTSTART:20240101T090000Z\nEND:VEVENT\nBEGIN:VEVENT\nUID:b\nDTSTART;VALUE=DATE:20240101\nEND:VEVENT\nEND:VCALENDAR\n"))
+	require.NoError(t, err)
+	assert.Error(t, allDay.Shift(time.Hour))
+	assert.Equal(t, "20240101T090000Z", allDay.Events()[0].GetProperty(ComponentPropertyDtStart).Value)
+	require.NoError(t, allDay.Shift(-48*time.Hour))
+	assert.Equal(t, "20231230", allDay.Events()[1].GetProperty(ComponentPropertyDtStart).Value)
+
+	require.NoError(t, cal.Shift(-2*time.Hour))
+	assert.Equal(t, "20240331T100000", event.GetProperty(ComponentPropertyDtStart).Value)
+
+	unresolvable, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nDTSTART;TZID=Not/AZone:20240101T090000\nEND:VEVENT\nEND:VCALENDAR\n"))
+	require.NoError(t, err)
+	assert.ErrorIs(t, unresolvable.Shift(24*time.Hour), ErrorUnresolvableTZID)
+	assert.Equal(t, "20240101T090000", unresolvable.Events()[0].GetProperty(ComponentPropertyDtStart).Value)
+
+	custom, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VTIMEZONE\nTZID:Custom/Office\nBEGIN:STANDARD\nDTSTART:19700101T000000\nTZOFFSETFROM:+0100\nTZOFFSETTO:+0100\nEND:STANDARD\nEND:VTIMEZONE\nBEGIN:VEVENT\nUID:a\nDTSTART;TZID=Custom/Office:20240101T090000\nEND:VEVENT\nEND:VCALENDAR\n"))
+	require.NoError(t, err)
+	require.NoError(t, custom.Shift(24*time.Hour))
+	assert.Equal(t, "20240102T090000", custom.Events()[0].GetProperty(ComponentPropertyDtStart).Value)
+}
+
+func TestCalendarNames(t *testing.T) {
+	cal := NewCalendar()
+	assert.Equal(t, "", cal.GetStandardName())
+	assert.Equal(t, "", cal.GetAppleCalName())
+
+	cal.SetStandardName("Standard")
+	assert.Equal(t, "Standard", cal.GetStandardName())
+	assert.Equal(t, "", cal.GetAppleCalName())
+	assert.NotContains(t, cal.Serialize(), "X-WR-CALNAME")
+
+	cal.SetAppleCalName("Apple")
+	assert.Equal(t, "Standard", cal.GetStandardName())
+	assert.Equal(t, "Apple", cal.GetAppleCalName())
+
+	cal.SetName("Both")
+	assert.Equal(t, "Both", cal.GetStandardName())
+	assert.Equal(t, "Both", cal.GetAppleCalName())
+}
+
+func TestSetRefreshIntervalDuration(t *testing.T) {
+	cal := NewCalendar()
+	assert.Error(t, cal.SetRefreshIntervalDuration(0))
+	assert.Error(t, cal.SetRefreshIntervalDuration(time.Millisecond))
+	assert.NoError(t, cal.SetRefreshIntervalDuration(12*time.Hour))
+	assert.Contains(t, cal.Serialize(), "REFRESH-INTERVAL;VALUE=DURATION:PT12H\n")
+	assert.NoError(t, cal.SetRefreshIntervalDuration(7*24*time.Hour))
+	assert.Contains(t, cal.Serialize(), "REFRESH-INTERVAL;VALUE=DURATION:P7D\n")
+}
+
+func TestRefreshIntervalRoundTrip(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nVERSION:2.0\nREFRESH-INTERVAL;VALUE=DURATION:PT1H\nEND:VCALENDAR\n"))
+	require.NoError(t, err)
+	p := cal.getProperty(PropertyRefreshInterval)
+	require.NotNil(t, p)
+	assert.Equal(t, "REFRESH-INTERVAL", p.IANAToken)
+	assert.Equal(t, []string{"DURATION"}, p.ICalParameters[string(ParameterValue)])
+
+	cal.SetRefreshInterval("PT2H")
+	assert.Len(t, cal.CalendarProperties, 2)
+	assert.Equal(t, "BEGIN:VCALENDAR\nVERSION:2.0\nREFRESH-INTERVAL;VALUE=DURATION:PT2H\nEND:VCALENDAR\n", cal.Serialize())
+}
+
+func TestApplyDefaultAlarm(t *testing.T) {
+	cal := NewCalendar()
+	withAlarm := cal.AddEvent("with-alarm")
+	withAlarm.AddAlarm().SetTrigger("-PT1H")
+	cal.AddEvent("without-alarm")
+	cal.AddTodo("todo")
+
+	cal.ApplyDefaultAlarm("-PT15M", "Reminder")
+	events := cal.Events()
+	require.Len(t, events[0].Alarms(), 1)
+	assert.Equal(t, "-PT1H", events[0].Alarms()[0].GetProperty(ComponentPropertyTrigger).Value)
+	require.Len(t, events[1].Alarms(), 1)
+	alarm := events[1].Alarms()[0]
+	assert.Equal(t, "DISPLAY", alarm.GetProperty(ComponentPropertyAction).Value)
+	assert.Equal(t, "-PT15M", alarm.GetProperty(ComponentPropertyTrigger).Value)
+	assert.Equal(t, "Reminder", alarm.GetProperty(ComponentPropertyDescription).Value)
+	assert.Empty(t, cal.Todos()[0].Components)
+}
+
+func TestSerializeRFC(t *testing.T) {
+	cal := NewCalendar()
+	cal.AddEvent("a").SetSummary("Summary")
+	text := cal.SerializeRFC(WithNewLineUnix)
+	assert.Equal(t, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//arran4//Golang ICS Library\r\nBEGIN:VEVENT\r\nUID:a\r\nSUMMARY:Summary\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n", text)
+	assert.Equal(t, cal.Serialize(WithNewLineWindows), text)
+
+	serializeConfig := defaultSerializationOptions()
+	serializeConfig.NewLine = "\n"
+	assert.Equal(t, text, cal.SerializeRFC(serializeConfig))
+	assert.Equal(t, "\n", serializeConfig.NewLine)
+}
+
+func TestUpsertEvent(t *testing.T) {
+	cal := NewCalendar()
+	cal.AddEvent("a").SetSummary("A")
+	cal.AddEvent("b").SetSummary("B")
+	override := NewEvent("a")
+	override.SetRecurrenceID(time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC))
+	override.SetSummary("A override")
+	cal.AddVEvent(override)
+
+	updated := NewEvent("a")
+	updated.SetSummary("A updated")
+	assert.True(t, cal.UpsertEvent(updated))
+	updatedOverride := NewEvent("a")
+	updatedOverride.SetRecurrenceID(time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC))
+	updatedOverride.SetSummary("A override updated")
+	assert.True(t, cal.UpsertEvent(updatedOverride))
+	assert.False(t, cal.UpsertEvent(NewEvent("c")))
+
+	var got []string
+	for _, e := range cal.Events()[:3] {
+		got = append(got, e.Id()+" "+e.GetProperty(ComponentPropertySummary).Value)
+	}
+	assert.Equal(t, []string{"a A updated", "b B", "a A override updated"}, got)
+	assert.Len(t, cal.Events(), 4)
+}
+
+func TestParseCalendarStrict(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		wantErr error
+	}{
+		{name: "known", body: "BEGIN:VEVENT\nUID:a\nSUMMARY:Summary\nIMAGE;VALUE=URI:https://example.com/a.png\nEND:VEVENT\n"},
+		{name: "extensions", body: "X-CUSTOM:a\nBEGIN:X-VENDOR\nX-DATA:b\nEND:X-VENDOR\n"},
+		{name: "unknown calendar property", body: "FOO:bar\n", wantErr: ErrorUnknownProperty},
+		{name: "unknown component property", body: "BEGIN:VEVENT\nUID:a\nFOO:bar\nEND:VEVENT\n", wantErr: ErrorUnknownProperty},
+		{name: "unknown component", body: "BEGIN:VFOO\nUID:a\nEND:VFOO\n", wantErr: ErrorUnknownComponent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := "BEGIN:VCALENDAR\nVERSION:2.0\n" + tt.body + "END:VCALENDAR\n"
+			_, err := ParseCalendar(strings.NewReader(input))
+			assert.NoError(t, err)
+			_, err = ParseCalendar(strings.NewReader(input), WithStrict(true))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	input := "BEGIN:VCALENDAR\nVERSION:2.0\nPRODID:-//arran4//Golang ICS Library\nBEGIN:VEVENT\nUID:a\nSUMMARY:Comma\\, semicolon\\;\nEND:VEVENT\nEND:VCALENDAR\n"
+	output, err := RoundTrip(input)
+	assert.NoError(t, err)
+	assert.Equal(t, input, output)
+
+	crlf := strings.ReplaceAll(input, "\n", "\r\n")
+	output, err = RoundTrip(crlf)
+	assert.NoError(t, err)
+	assert.Equal(t, crlf, output)
+
+	_, err = RoundTrip("BEGIN:VEVENT\nEND:VEVENT\n")
+	assert.Error(t, err)
+}
+
+func TestComponentAt(t *testing.T) {
+	cal := NewCalendar()
+	event := cal.AddEvent("a")
+	todo := cal.AddTodo("b")
+	assert.Equal(t, Component(event), cal.ComponentAt(0))
+	assert.Equal(t, Component(todo), cal.ComponentAt(1))
+	assert.Nil(t, cal.ComponentAt(2))
+	assert.Nil(t, cal.ComponentAt(-1))
+
+	replacement := NewEvent("c")
+	assert.NoError(t, cal.ReplaceComponentAt(1, replacement))
+	assert.Equal(t, Component(replacement), cal.ComponentAt(1))
+	assert.ErrorIs(t, cal.ReplaceComponentAt(2, replacement), ErrorComponentIndexOutOfRange)
+	assert.ErrorIs(t, cal.ReplaceComponentAt(-1, replacement), ErrorComponentIndexOutOfRange)
+	assert.Error(t, cal.ReplaceComponentAt(0, nil))
+	assert.Len(t, cal.Components, 2)
+}
+
+func TestParseCalendarLatin1Fallback(t *testing.T) {
+	input := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nBEGIN:VEVENT\r\nUID:a\r\nSUMMARY:Caf\xc3\xa9 \x93review\x94 \x97 Q1\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n"
+	cal, err := ParseCalendar(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.False(t, utf8.ValidString(cal.Events()[0].GetProperty(ComponentPropertySummary).Value))
+
+	cal, err = ParseCalendar(strings.NewReader(input), WithLatin1Fallback(true))
+	require.NoError(t, err)
+	assert.Equal(t, "Café “review” — Q1", cal.Events()[0].GetProperty(ComponentPropertySummary).Value)
+}
+
+func TestPreserveRawValues(t *testing.T) {
+	input := `BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Example//EN
+X-WR-CALNAME:Team\Nschedule
+BEGIN:VEVENT
+UID:raw-1
+SUMMARY:Lunch\, then coffee
+DESCRIPTION:First line\Nsecond line\:colon
+LOCATION:Room 1\Nbuilding 2
+END:VEVENT
+END:VCALENDAR
+`
+	cal, err := ParseCalendar(strings.NewReader(input), WithPreserveRawValues(true))
+	require.NoError(t, err)
+	assert.Equal(t, input, strings.ReplaceAll(cal.Serialize(defaultSerializationOptions()), "\r\n", "\n"))
+
+	event := cal.Events()[0]
+	assert.Equal(t, "First line\nsecond line\\:colon", event.GetProperty(ComponentPropertyDescription).Value)
+	event.SetLocation("Room 2\nbuilding 2")
+	assert.Contains(t, cal.Serialize(defaultSerializationOptions()), "LOCATION:Room 2\\nbuilding 2\n")
+
+	cal, err = ParseCalendar(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Contains(t, cal.Serialize(defaultSerializationOptions()), "DESCRIPTION:First line\\nsecond line\\\\:colon\n")
+}
+
+func TestLenientUnfoldedContinuation(t *testing.T) {
+	// As produced by a booking system which folds long lines with a bare CRLF
+	input := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//Booking//EN\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:booking-1234\r\n" +
+		"DTSTART:20240301T100000Z\r\n" +
+		"SUMMARY:Consultation with Dr. Smith\r\n" +
+		"DESCRIPTION:Please arrive 10 minutes early to complete the intake form\\, a\r\n" +
+		"nd bring your insurance card.\\n\\nDirections: take the lift to floor 3\r\n" +
+		"(reception is on the left).\r\n" +
+		"LOCATION:Clinic\r\n" +
+		"\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	const description = "Please arrive 10 minutes early to complete the intake form, and bring your insurance card.\n\nDirections: take the lift to floor 3(reception is on the left)."
+
+	cal, err := ParseCalendar(strings.NewReader(input), WithLenient(true))
+	require.NoError(t, err)
+	event := cal.Events()[0]
+	assert.Equal(t, description, event.GetProperty(ComponentPropertyDescription).Value)
+	assert.Equal(t, "Clinic", event.GetProperty(ComponentPropertyLocation).Value)
+
+	_, err = ParseCalendar(strings.NewReader(input))
+	assert.Error(t, err)
+}
+
+func TestCalendarStats(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VTIMEZONE
+TZID:Europe/Berlin
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:a
+DTSTART:20240105T090000Z
+DTEND:20240105T100000Z
+RRULE:FREQ=WEEKLY
+END:VEVENT
+BEGIN:VEVENT
+UID:b
+DTSTART;VALUE=DATE:20240301
+DTEND;VALUE=DATE:20240303
+END:VEVENT
+BEGIN:VEVENT
+UID:c
+DTSTART:not a time
+END:VEVENT
+BEGIN:VEVENT
+UID:d
+DTSTART:20240201T090000Z
+DTEND:garbage
+END:VEVENT
+BEGIN:VTODO
+UID:t
+END:VTODO
+BEGIN:VJOURNAL
+UID:j
+END:VJOURNAL
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	stats := cal.Stats()
+	assert.Equal(t, 4, stats.Events)
+	assert.Equal(t, 1, stats.Todos)
+	assert.Equal(t, 1, stats.Journals)
+	assert.Equal(t, 1, stats.RecurringEvents)
+	assert.Equal(t, 1, stats.AllDayEvents)
+	assert.Equal(t, 1, stats.Timezones)
+	assert.Equal(t, 1, stats.UnparseableEvents)
+	assert.Equal(t, time.Date(2024, 1, 5, 9, 0, 0, 0, time.UTC), stats.Earliest)
+	assert.Equal(t, "20240303", stats.Latest.Format(icalDateFormatLocal))
+
+	assert.Equal(t, CalendarStats{}, NewCalendar().Stats())
+}
+
+func TestSerializeCalendars(t *testing.T) {
+	first := NewCalendarFor("first")
+	first.AddEvent("a")
+	second := NewCalendarFor("second")
+	second.AddEvent("b")
+
+	b := &bytes.Buffer{}
+	require.NoError(t, SerializeCalendars(b, []*Calendar{first, second}, WithNoTrailingNewline(true), WithNewLineWindows))
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//first//Golang ICS Library\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:a\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR\r\n"+
+		"BEGIN:VCALENDAR\r\n"+
+		"VERSION:2.0\r\n"+
+		"PRODID:-//second//Golang ICS Library\r\n"+
+		"BEGIN:VEVENT\r\n"+
+		"UID:b\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR", b.String())
+
+	assert.Error(t, SerializeCalendars(b, []*Calendar{first}, 42))
+	b.Reset()
+	require.NoError(t, SerializeCalendars(b, nil))
+	assert.Equal(t, "", b.String())
+}
+
+func TestFindEventsBySummary(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+SUMMARY:Daily Standup
+END:VEVENT
+BEGIN:VEVENT
+UID:b
+SUMMARY:Retro\, then standup prep
+END:VEVENT
+BEGIN:VEVENT
+UID:c
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	ids := func(events []*VEvent) []string {
+		var r []string
+		for _, e := range events {
+			r = append(r, e.Id())
+		}
+		return r
+	}
+	assert.Equal(t, []string{"a"}, ids(cal.FindEventsBySummary("Standup", false)))
+	assert.Equal(t, []string{"a", "b"}, ids(cal.FindEventsBySummary("STANDUP", true)))
+	assert.Equal(t, []string{"b"}, ids(cal.FindEventsBySummary("Retro, then", false)))
+	assert.Equal(t, []string{"a", "b"}, ids(cal.FindEventsBySummary("", false)))
+	assert.Empty(t, cal.FindEventsBySummary("planning", true))
+}
+
+func TestParseWithValidate(t *testing.T) {
+	input := `BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART;TZID=Customized Time Zone:20240301T100000
+END:VEVENT
+BEGIN:VEVENT
+UID:b
+END:VEVENT
+END:VCALENDAR
+`
+	cal, err := ParseCalendar(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Len(t, cal.Events(), 2)
+
+	cal, err = ParseCalendar(strings.NewReader(input), WithValidate(true))
+	assert.Nil(t, cal)
+	assert.ErrorIs(t, err, ErrorUnresolvableTZID)
+	assert.ErrorIs(t, err, ErrorMissingRequiredProperty)
+}
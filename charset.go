@@ -0,0 +1,31 @@
+package ics
+
+import "unicode/utf8"
+
+// windows1252 maps the bytes 0x80 to 0x9F of Windows-1252 to their code points. The remaining bytes match ISO-8859-1,
+// so share their value with the code point. Undefined bytes are mapped to the C1 control of the same value.
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// fixWindows1252 returns b with every byte which is not part of valid UTF-8 decoded as Windows-1252
+func fixWindows1252(b []byte) []byte {
+	if utf8.Valid(b) {
+		return b
+	}
+	r := make([]byte, 0, len(b)+len(b)/2)
+	for len(b) > 0 {
+		c, size := utf8.DecodeRune(b)
+		if c == utf8.RuneError && size <= 1 {
+			c = rune(b[0])
+			if c >= 0x80 && c < 0xA0 {
+				c = windows1252[c-0x80]
+			}
+			size = 1
+		}
+		r = utf8.AppendRune(r, c)
+		b = b[size:]
+	}
+	return r
+}
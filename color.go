@@ -0,0 +1,88 @@
+package ics
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// css3ColorNames are the CSS3 extended color keywords, which RFC7986 specifies for COLOR
+var css3ColorNames = map[string]bool{
+	"aliceblue": true, "antiquewhite": true, "aqua": true, "aquamarine": true, "azure": true, "beige": true,
+	"bisque": true, "black": true, "blanchedalmond": true, "blue": true, "blueviolet": true, "brown": true,
+	"burlywood": true, "cadetblue": true, "chartreuse": true, "chocolate": true, "coral": true,
+	"cornflowerblue": true, "cornsilk": true, "crimson": true, "cyan": true, "darkblue": true, "darkcyan": true,
+	"darkgoldenrod": true, "darkgray": true, "darkgreen": true, "darkgrey": true, "darkkhaki": true,
+	"darkmagenta": true, "darkolivegreen": true, "darkorange": true, "darkorchid": true, "darkred": true,
+	"darksalmon": true, "darkseagreen": true, "darkslateblue": true, "darkslategray": true, "darkslategrey": true,
+	"darkturquoise": true, "darkviolet": true, "deeppink": true, "deepskyblue": true, "dimgray": true,
+	"dimgrey": true, "dodgerblue": true, "firebrick": true, "floralwhite": true, "forestgreen": true,
+	"fuchsia": true, "gainsboro": true, "ghostwhite": true, "gold": true, "goldenrod": true, "gray": true,
+	"green": true, "greenyellow": true, "grey": true, "honeydew": true, "hotpink": true, "indianred": true,
+	"indigo": true, "ivory": true, "khaki": true, "lavender": true, "lavenderblush": true, "lawngreen": true,
+	"lemonchiffon": true, "lightblue": true, "lightcoral": true, "lightcyan": true, "lightgoldenrodyellow": true,
+	"lightgray": true, "lightgreen": true, "lightgrey": true, "lightpink": true, "lightsalmon": true,
+	"lightseagreen": true, "lightskyblue": true, "lightslategray": true, "lightslategrey": true,
+	"lightsteelblue": true, "lightyellow": true, "lime": true, "limegreen": true, "linen": true, "magenta": true,
+	"maroon": true, "mediumaquamarine": true, "mediumblue": true, "mediumorchid": true, "mediumpurple": true,
+	"mediumseagreen": true, "mediumslateblue": true, "mediumspringgreen": true, "mediumturquoise": true,
+	"mediumvioletred": true, "midnightblue": true, "mintcream": true, "mistyrose": true, "moccasin": true,
+	"navajowhite": true, "navy": true, "oldlace": true, "olive": true, "olivedrab": true, "orange": true,
+	"orangered": true, "orchid": true, "palegoldenrod": true, "palegreen": true, "paleturquoise": true,
+	"palevioletred": true, "papayawhip": true, "peachpuff": true, "peru": true, "pink": true, "plum": true,
+	"powderblue": true, "purple": true, "red": true, "rosybrown": true, "royalblue": true, "saddlebrown": true,
+	"salmon": true, "sandybrown": true, "seagreen": true, "seashell": true, "sienna": true, "silver": true,
+	"skyblue": true, "slateblue": true, "slategray": true, "slategrey": true, "snow": true, "springgreen": true,
+	"steelblue": true, "tan": true, "teal": true, "thistle": true, "tomato": true, "turquoise": true,
+	"violet": true, "wheat": true, "white": true, "whitesmoke": true, "yellow": true, "yellowgreen": true,
+}
+
+var hexColor = regexp.MustCompile("^#([0-9A-Fa-f]{3}|[0-9A-Fa-f]{6})$")
+
+// checkColor returns an error wrapping ErrorInvalidColor unless name is a CSS3 color name. Hex colors get a dedicated
+// message, as they are a common mistake.
+func checkColor(name string) error {
+	if css3ColorNames[strings.ToLower(name)] {
+		return nil
+	}
+	if hexColor.MatchString(name) {
+		return fmt.Errorf("%w: %q is a hex color which RFC7986 does not permit, use SetColor to set it regardless", ErrorInvalidColor, name)
+	}
+	return fmt.Errorf("%w: %q", ErrorInvalidColor, name)
+}
+
+// SetColorChecked sets COLOR after checking name is a CSS3 color name as RFC7986 requires, returning an error wrapping
+// ErrorInvalidColor otherwise, as clients following RFC7986 ignore other values. Hex colors such as #40e0d0 are
+// rejected too; some clients render them, so SetColor can still be used to set one knowingly.
+func (cb *ComponentBase) SetColorChecked(name string, params ...PropertyParameter) error {
+	if err := checkColor(name); err != nil {
+		return err
+	}
+	cb.SetColor(name, params...)
+	return nil
+}
+
+// GetColor returns the COLOR of the component, or "" if not set.
+func (cb *ComponentBase) GetColor() string {
+	if p := cb.GetProperty(ComponentPropertyColor); p != nil {
+		return p.Value
+	}
+	return ""
+}
+
+// SetColorChecked sets the calendar's COLOR, checking it as ComponentBase.SetColorChecked does.
+func (cal *Calendar) SetColorChecked(name string, params ...PropertyParameter) error {
+	if err := checkColor(name); err != nil {
+		return err
+	}
+	cal.SetColor(name, params...)
+	return nil
+}
+
+// GetColor returns the calendar's COLOR, or "" if not set.
+func (cal *Calendar) GetColor() string {
+	if p := cal.getProperty(PropertyColor); p != nil {
+		return p.Value
+	}
+	return ""
+}
@@ -0,0 +1,28 @@
+package ics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetColorChecked(t *testing.T) {
+	e := NewEvent("test-color")
+	assert.Equal(t, "", e.GetColor())
+	assert.NoError(t, e.SetColorChecked("turquoise"))
+	assert.Equal(t, "turquoise", e.GetColor())
+	assert.NoError(t, e.SetColorChecked("DarkSlateGrey"))
+	assert.Equal(t, "DarkSlateGrey", e.GetColor())
+
+	err := e.SetColorChecked("#40e0d0")
+	assert.ErrorIs(t, err, ErrorInvalidColor)
+	assert.Contains(t, err.Error(), "hex")
+	assert.ErrorIs(t, e.SetColorChecked("blurple"), ErrorInvalidColor)
+	assert.Equal(t, "DarkSlateGrey", e.GetColor())
+
+	cal := NewCalendar()
+	assert.ErrorIs(t, cal.SetColorChecked("rgb(1,2,3)"), ErrorInvalidColor)
+	assert.Equal(t, "", cal.GetColor())
+	assert.NoError(t, cal.SetColorChecked("navy"))
+	assert.Equal(t, "navy", cal.GetColor())
+}
@@ -5,14 +5,18 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"html"
 	"io"
+	"net/url"
+	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
-// Component To determine what this is please use a type switch or typecast to each of:
+// Component To determine what this is please use ComponentType or a type switch or typecast to each of:
 // - *VEvent
 // - *VTodo
 // - *VBusy
@@ -21,6 +25,7 @@ type Component interface {
 	UnknownPropertiesIANAProperties() []IANAProperty
 	SubComponents() []Component
 	SerializeTo(b io.Writer, serialConfig *SerializationConfiguration) error
+	ComponentType() ComponentType
 }
 
 var (
@@ -28,6 +33,11 @@ var (
 	_ Component = (*VTodo)(nil)
 	_ Component = (*VBusy)(nil)
 	_ Component = (*VJournal)(nil)
+	_ Component = (*VTimezone)(nil)
+	_ Component = (*VAlarm)(nil)
+	_ Component = (*Standard)(nil)
+	_ Component = (*Daylight)(nil)
+	_ Component = (*GeneralComponent)(nil)
 )
 
 type ComponentBase struct {
@@ -39,13 +49,61 @@ func (cb *ComponentBase) UnknownPropertiesIANAProperties() []IANAProperty {
 	return cb.Properties
 }
 
+// UnknownProperties returns the properties whose token is not one of the Property constants, such as vendor extensions.
+// Unlike UnknownPropertiesIANAProperties, which returns every property, only the unmodeled properties are returned.
+func (cb *ComponentBase) UnknownProperties() []IANAProperty {
+	return unknownProperties(cb.Properties)
+}
+
+func unknownProperties(properties []IANAProperty) []IANAProperty {
+	var r []IANAProperty
+	for _, p := range properties {
+		if !Property(p.IANAToken).IsKnown() {
+			r = append(r, p)
+		}
+	}
+	return r
+}
+
 func (cb *ComponentBase) SubComponents() []Component {
 	return cb.Components
 }
 
+// canonicalPropertyOrder is the order properties are written in when SerializationConfiguration.CanonicalPropertyOrder
+// is set. Properties not listed are written afterwards.
+var canonicalPropertyOrder = []ComponentProperty{
+	ComponentPropertyUniqueId, ComponentPropertyDtstamp, ComponentPropertyDtStart, ComponentPropertyDtEnd,
+	ComponentPropertyDuration, ComponentPropertyDue, ComponentPropertyRecurrenceId, ComponentPropertyRrule,
+	ComponentPropertyRdate, ComponentPropertyExrule, ComponentPropertyExdate, ComponentPropertySequence,
+	ComponentPropertyStatus, ComponentPropertySummary, ComponentPropertyDescription, ComponentPropertyLocation,
+	ComponentPropertyGeo, ComponentPropertyOrganizer, ComponentPropertyAttendee, ComponentPropertyClass,
+	ComponentPropertyTransp, ComponentPropertyPriority, ComponentPropertyCategories, ComponentPropertyUrl,
+	ComponentPropertyCreated, ComponentPropertyLastModified,
+}
+
+func (cb *ComponentBase) orderedProperties(serialConfig *SerializationConfiguration) []IANAProperty {
+	if !serialConfig.CanonicalPropertyOrder {
+		return cb.Properties
+	}
+	rank := func(p IANAProperty) int {
+		for i, cp := range canonicalPropertyOrder {
+			if p.IANAToken == string(cp) {
+				return i
+			}
+		}
+		return len(canonicalPropertyOrder)
+	}
+	properties := make([]IANAProperty, len(cb.Properties))
+	copy(properties, cb.Properties)
+	sort.SliceStable(properties, func(i, j int) bool {
+		return rank(properties[i]) < rank(properties[j])
+	})
+	return properties
+}
+
 func (cb *ComponentBase) serializeThis(writer io.Writer, componentType ComponentType, serialConfig *SerializationConfiguration) error {
 	_, _ = fmt.Fprint(writer, "BEGIN:"+componentType, serialConfig.NewLine)
-	for _, p := range cb.Properties {
+	for _, p := range cb.orderedProperties(serialConfig) {
 		err := p.serialize(writer, serialConfig)
 		if err != nil {
 			return err
@@ -61,6 +119,49 @@ func (cb *ComponentBase) serializeThis(writer io.Writer, componentType Component
 	return err
 }
 
+// clone returns a deep copy of the component base including sub components
+func (cb *ComponentBase) clone() ComponentBase {
+	r := ComponentBase{}
+	if cb.Properties != nil {
+		r.Properties = make([]IANAProperty, len(cb.Properties))
+		for i := range cb.Properties {
+			r.Properties[i] = IANAProperty{cb.Properties[i].clone()}
+		}
+	}
+	if cb.Components != nil {
+		r.Components = make([]Component, len(cb.Components))
+		for i := range cb.Components {
+			r.Components[i] = CloneComponent(cb.Components[i])
+		}
+	}
+	return r
+}
+
+// CloneComponent returns a deep copy of c. Component implementations from outside this package are returned as is.
+func CloneComponent(c Component) Component {
+	switch c := c.(type) {
+	case *VEvent:
+		return &VEvent{c.clone()}
+	case *VTodo:
+		return &VTodo{c.clone()}
+	case *VJournal:
+		return &VJournal{c.clone()}
+	case *VBusy:
+		return &VBusy{c.clone()}
+	case *VTimezone:
+		return &VTimezone{c.clone()}
+	case *VAlarm:
+		return &VAlarm{c.clone()}
+	case *Standard:
+		return &Standard{c.clone()}
+	case *Daylight:
+		return &Daylight{c.clone()}
+	case *GeneralComponent:
+		return &GeneralComponent{ComponentBase: c.clone(), Token: c.Token}
+	}
+	return c
+}
+
 func NewComponent(uniqueId string) ComponentBase {
 	return ComponentBase{
 		Properties: []IANAProperty{
@@ -80,6 +181,22 @@ func (cb *ComponentBase) GetProperty(componentProperty ComponentProperty) *IANAP
 	return nil
 }
 
+// LookupProperty returns the first match for the particular property you're after and whether it was found.
+func (cb *ComponentBase) LookupProperty(componentProperty ComponentProperty) (*IANAProperty, bool) {
+	p := cb.GetProperty(componentProperty)
+	return p, p != nil
+}
+
+// LookupValue returns the value of the first match for the particular property you're after and whether it was found,
+// distinguishing a property which is missing from one with an empty value.
+func (cb *ComponentBase) LookupValue(componentProperty ComponentProperty) (string, bool) {
+	p := cb.GetProperty(componentProperty)
+	if p == nil {
+		return "", false
+	}
+	return p.Value, true
+}
+
 // GetProperties returns all matches for the particular property you're after. Please consider using:
 // ComponentProperty.Singular/ComponentProperty.Multiple to determine if GetProperty or GetProperties is more appropriate.
 func (cb *ComponentBase) GetProperties(componentProperty ComponentProperty) []*IANAProperty {
@@ -92,6 +209,17 @@ func (cb *ComponentBase) GetProperties(componentProperty ComponentProperty) []*I
 	return result
 }
 
+// PropertyMap returns a snapshot of the component's property values keyed by property, in the order they appear. It is
+// intended for display and logging; parameters are lost, so use GetProperties when full fidelity is required.
+func (cb *ComponentBase) PropertyMap() map[ComponentProperty][]string {
+	result := map[ComponentProperty][]string{}
+	for i := range cb.Properties {
+		k := ComponentProperty(cb.Properties[i].IANAToken)
+		result[k] = append(result[k], cb.Properties[i].Value)
+	}
+	return result
+}
+
 // HasProperty returns true if a component property is in the component.
 func (cb *ComponentBase) HasProperty(componentProperty ComponentProperty) bool {
 	for i := range cb.Properties {
@@ -146,6 +274,13 @@ func (cb *ComponentBase) AddProperty(property ComponentProperty, value string, p
 	cb.Properties = append(cb.Properties, r)
 }
 
+// AddPropertyP behaves like AddProperty but returns the added property for further modification. The pointer is only
+// valid until the component's properties are next added to or removed.
+func (cb *ComponentBase) AddPropertyP(property ComponentProperty, value string, params ...PropertyParameter) *IANAProperty {
+	cb.AddProperty(property, value, params...)
+	return &cb.Properties[len(cb.Properties)-1]
+}
+
 // RemoveProperty removes from the component all properties that is of a particular property type, returning an slice of
 // removed entities
 func (cb *ComponentBase) RemoveProperty(removeProp ComponentProperty) []IANAProperty {
@@ -186,6 +321,108 @@ func (cb *ComponentBase) RemovePropertyByFunc(removeProp ComponentProperty, remo
 	return removedProperties
 }
 
+// Deduplicate removes repeats of the properties which ComponentProperty.Singular says may not occur more than once in
+// any component, such as a second SUMMARY left by a merge, so strict clients accept the component. The first of each is
+// kept, see DeduplicateKeepLast. The removed properties are returned.
+func (cb *ComponentBase) Deduplicate() []IANAProperty {
+	return cb.deduplicate(nil, false)
+}
+
+// DeduplicateKeepLast is Deduplicate but keeps the last of each repeated property, for when later values supersede
+// earlier ones.
+func (cb *ComponentBase) DeduplicateKeepLast() []IANAProperty {
+	return cb.deduplicate(nil, true)
+}
+
+// Deduplicate is ComponentBase.Deduplicate but also removes repeated DESCRIPTIONs, which a VEVENT may only have one of.
+func (event *VEvent) Deduplicate() []IANAProperty {
+	return event.deduplicate(event, false)
+}
+
+// DeduplicateKeepLast is ComponentBase.DeduplicateKeepLast but also removes repeated DESCRIPTIONs.
+func (event *VEvent) DeduplicateKeepLast() []IANAProperty {
+	return event.deduplicate(event, true)
+}
+
+// Deduplicate is ComponentBase.Deduplicate but also removes repeated DESCRIPTIONs, which a VTODO may only have one of.
+func (todo *VTodo) Deduplicate() []IANAProperty {
+	return todo.deduplicate(todo, false)
+}
+
+// DeduplicateKeepLast is ComponentBase.DeduplicateKeepLast but also removes repeated DESCRIPTIONs.
+func (todo *VTodo) DeduplicateKeepLast() []IANAProperty {
+	return todo.deduplicate(todo, true)
+}
+
+// Deduplicate is ComponentBase.Deduplicate but also removes repeated DESCRIPTIONs, which a VALARM may only have one of.
+func (c *VAlarm) Deduplicate() []IANAProperty {
+	return c.deduplicate(c, false)
+}
+
+// DeduplicateKeepLast is ComponentBase.DeduplicateKeepLast but also removes repeated DESCRIPTIONs.
+func (c *VAlarm) DeduplicateKeepLast() []IANAProperty {
+	return c.deduplicate(c, true)
+}
+
+// deduplicate removes repeats of the properties which are singular in c, which may be nil
+func (cb *ComponentBase) deduplicate(c Component, keepLast bool) []IANAProperty {
+	keep := map[ComponentProperty]int{}
+	for i := range cb.Properties {
+		cp := ComponentProperty(cb.Properties[i].IANAToken)
+		if _, seen := keep[cp]; !seen || keepLast {
+			keep[cp] = i
+		}
+	}
+	var keptProperties []IANAProperty
+	var removedProperties []IANAProperty
+	for i := range cb.Properties {
+		cp := ComponentProperty(cb.Properties[i].IANAToken)
+		if cp.Singular(c) && keep[cp] != i {
+			removedProperties = append(removedProperties, cb.Properties[i])
+		} else {
+			keptProperties = append(keptProperties, cb.Properties[i])
+		}
+	}
+	cb.Properties = keptProperties
+	return removedProperties
+}
+
+// RemoveParameterEverywhere removes the parameter from every property of the component and its sub-components, such
+// as VALARMs, returning how many were removed. Names are matched case-insensitively, and a name ending in * matches
+// every parameter with that prefix, so "X-APPLE-*" strips all of Apple's vendor parameters.
+func (cb *ComponentBase) RemoveParameterEverywhere(name Parameter) int {
+	removed := removeParameter(cb.Properties, name)
+	for _, c := range cb.Components {
+		removed += removeParameterFromComponent(c, name)
+	}
+	return removed
+}
+
+func removeParameterFromComponent(c Component, name Parameter) int {
+	removed := removeParameter(c.UnknownPropertiesIANAProperties(), name)
+	for _, sc := range c.SubComponents() {
+		removed += removeParameterFromComponent(sc, name)
+	}
+	return removed
+}
+
+func removeParameter(properties []IANAProperty, name Parameter) int {
+	prefix, wildcard := strings.CutSuffix(strings.ToUpper(string(name)), "*")
+	removed := 0
+	for i := range properties {
+		for k := range properties[i].ICalParameters {
+			upper := strings.ToUpper(k)
+			if upper == prefix || wildcard && strings.HasPrefix(upper, prefix) {
+				delete(properties[i].ICalParameters, k)
+				removed++
+			}
+		}
+	}
+	return removed
+}
+
+// DATE-TIME values have whole second precision, so the Set*At and Set*Time helpers drop any fraction of a second and
+// the Get*At helpers never return one. Compare times which have been round-tripped against t.Truncate(time.Second).
 const (
 	icalTimestampFormatUtc   = "20060102T150405Z"
 	icalTimestampFormatLocal = "20060102T150405"
@@ -207,6 +444,15 @@ func (cb *ComponentBase) SetModifiedAt(t time.Time, params ...PropertyParameter)
 	cb.SetProperty(ComponentPropertyLastModified, t.UTC().Format(icalTimestampFormatUtc), params...)
 }
 
+// Touch records a change made at now, setting LAST-MODIFIED and DTSTAMP, and CREATED if it is not already set.
+func (cb *ComponentBase) Touch(now time.Time) {
+	if !cb.HasProperty(ComponentPropertyCreated) {
+		cb.SetCreatedTime(now)
+	}
+	cb.SetDtStampTime(now)
+	cb.SetModifiedAt(now)
+}
+
 func (cb *ComponentBase) SetSequence(seq int, params ...PropertyParameter) {
 	cb.SetProperty(ComponentPropertySequence, strconv.Itoa(seq), params...)
 }
@@ -223,11 +469,22 @@ func (cb *ComponentBase) SetAllDayStartAt(t time.Time, params ...PropertyParamet
 	)
 }
 
+// SetEndAt sets DTEND, removing any DURATION as the two are mutually exclusive.
 func (cb *ComponentBase) SetEndAt(t time.Time, params ...PropertyParameter) {
+	cb.RemoveProperty(ComponentPropertyDuration)
 	cb.SetProperty(ComponentPropertyDtEnd, t.UTC().Format(icalTimestampFormatUtc), params...)
 }
 
+// SetRecurrenceID marks the component as an override of the instance of a recurring component that originally started at
+// t. To split a series, modifying this and all following instances, pass WithRange(string(RecurrenceRangeThisAndFuture))
+// in which case the override applies to the identified instance and every instance after it.
+func (cb *ComponentBase) SetRecurrenceID(t time.Time, params ...PropertyParameter) {
+	cb.SetProperty(ComponentPropertyRecurrenceId, t.UTC().Format(icalTimestampFormatUtc), params...)
+}
+
+// SetAllDayEndAt sets DTEND as a date, removing any DURATION as the two are mutually exclusive.
 func (cb *ComponentBase) SetAllDayEndAt(t time.Time, params ...PropertyParameter) {
+	cb.RemoveProperty(ComponentPropertyDuration)
 	cb.SetProperty(
 		ComponentPropertyDtEnd,
 		t.Format(icalDateFormatLocal),
@@ -239,7 +496,8 @@ func (cb *ComponentBase) SetAllDayEndAt(t time.Time, params ...PropertyParameter
 // This function will set either the end or start time of an event depending what is already given.
 // The duration defines the length of a event relative to start or end time.
 //
-// Notice: It will not set the DURATION key of the ics - only DTSTART and DTEND will be affected.
+// Notice: It will not set the DURATION key of the ics - only DTSTART and DTEND will be affected. Any existing DURATION is
+// removed when DTEND is set, as the two are mutually exclusive.
 func (cb *ComponentBase) SetDuration(d time.Duration) error {
 	startProp := cb.GetProperty(ComponentPropertyDtStart)
 	if startProp != nil {
@@ -270,6 +528,7 @@ func (cb *ComponentBase) SetDuration(d time.Duration) error {
 	return errors.New("start or end not yet defined")
 }
 
+// GetEndAt returns the DTEND as given, see VEvent.EffectiveEnd for the end implied when it is absent.
 func (cb *ComponentBase) GetEndAt() (time.Time, error) {
 	return cb.getTimeProp(ComponentPropertyDtEnd, false)
 }
@@ -279,11 +538,28 @@ func (cb *ComponentBase) getTimeProp(componentProperty ComponentProperty, expect
 	if timeProp == nil {
 		return time.Time{}, fmt.Errorf("%w: %s", ErrorPropertyNotFound, componentProperty)
 	}
+	return timeProp.parseTime(expectAllDay)
+}
+
+// parseTime reads the property's value as a time, returning a *TimeParseError if it can not be.
+func (timeProp *BaseProperty) parseTime(expectAllDay bool) (time.Time, error) {
+	t, err := timeProp.parseTimeValue(expectAllDay)
+	if err != nil {
+		return time.Time{}, &TimeParseError{
+			Property: ComponentProperty(timeProp.IANAToken),
+			Value:    timeProp.Value,
+			Reason:   err.Error(),
+			Err:      err,
+		}
+	}
+	return t, nil
+}
 
-	timeVal := timeProp.BaseProperty.Value
+func (timeProp *BaseProperty) parseTimeValue(expectAllDay bool) (time.Time, error) {
+	timeVal := timeProp.Value
 	matched := timeStampVariations.FindStringSubmatch(timeVal)
 	if matched == nil {
-		return time.Time{}, fmt.Errorf("time value not matched, got '%s'", timeVal)
+		return time.Time{}, errors.New("time value not matched")
 	}
 	tOrZGrp := matched[2]
 	zGrp := matched[4]
@@ -317,7 +593,7 @@ func (cb *ComponentBase) getTimeProp(componentProperty ComponentProperty, expect
 			}
 		}
 
-		return time.Time{}, fmt.Errorf("time value matched but unsupported all-day timestamp, got '%s'", timeVal)
+		return time.Time{}, errors.New("time value matched but unsupported all-day timestamp")
 	}
 
 	switch {
@@ -339,9 +615,10 @@ func (cb *ComponentBase) getTimeProp(componentProperty ComponentProperty, expect
 		}
 	}
 
-	return time.Time{}, fmt.Errorf("time value matched but not supported, got '%s'", timeVal)
+	return time.Time{}, errors.New("time value matched but not supported")
 }
 
+// GetStartAt returns DTSTART, which as for all the Get*At helpers is truncated to whole seconds.
 func (cb *ComponentBase) GetStartAt() (time.Time, error) {
 	return cb.getTimeProp(ComponentPropertyDtStart, false)
 }
@@ -370,6 +647,120 @@ func (cb *ComponentBase) SetDescription(s string, params ...PropertyParameter) {
 	cb.SetProperty(ComponentPropertyDescription, s, params...)
 }
 
+// SetDescriptionRich sets DESCRIPTION to the plain text with an ALTREP pointing to a richer representation, such as an
+// HTML data URI, and a LANGUAGE. A nil altrep or empty lang is omitted.
+func (cb *ComponentBase) SetDescriptionRich(plain string, altrep *url.URL, lang string) {
+	var params []PropertyParameter
+	if altrep != nil {
+		params = append(params, WithAlternativeRepresentation(altrep))
+	}
+	if lang != "" {
+		params = append(params, WithLanguage(lang))
+	}
+	cb.SetDescription(plain, params...)
+}
+
+// DescriptionAltRep returns the ALTREP of the DESCRIPTION if there is one which is a valid URI
+func (cb *ComponentBase) DescriptionAltRep() (*url.URL, bool) {
+	p := cb.GetProperty(ComponentPropertyDescription)
+	if p == nil {
+		return nil, false
+	}
+	u, err := p.AltRep()
+	if err != nil {
+		return nil, false
+	}
+	return u, true
+}
+
+// PlainDescription returns the plain text DESCRIPTION, or "" if not set.
+func (cb *ComponentBase) PlainDescription() string {
+	if p := cb.GetProperty(ComponentPropertyDescription); p != nil {
+		return p.Value
+	}
+	return ""
+}
+
+// SetHTMLDescription sets the HTML description as Outlook does, in X-ALT-DESC with FMTTYPE=text/html, along with a plain
+// text DESCRIPTION derived from it for other clients.
+func (cb *ComponentBase) SetHTMLDescription(html string) {
+	cb.SetProperty(ComponentPropertyXAltDesc, html, WithFmtType("text/html"))
+	cb.SetDescription(htmlToText(html))
+}
+
+var (
+	htmlLineBreaks = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|h[1-6]|tr)\s*>`)
+	htmlTags       = regexp.MustCompile(`<[^>]*>`)
+	htmlBlankLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText reduces HTML to plain text by dropping tags, breaking lines at line and block ends and decoding entities.
+func htmlToText(s string) string {
+	s = htmlLineBreaks.ReplaceAllString(s, "\n")
+	s = htmlTags.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = htmlBlankLines.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
+
+// HTMLDescription returns the HTML description from an X-ALT-DESC with FMTTYPE=text/html, as set by SetHTMLDescription,
+// otherwise from the DESCRIPTION's ALTREP when it is a data:text/html URI.
+func (cb *ComponentBase) HTMLDescription() (string, bool) {
+	for _, p := range cb.GetProperties(ComponentPropertyXAltDesc) {
+		if fmtType, err := p.parameterValue(ParameterFmttype); err == nil && strings.EqualFold(fmtType, "text/html") {
+			return p.Value, true
+		}
+	}
+	u, ok := cb.DescriptionAltRep()
+	if !ok {
+		return "", false
+	}
+	mime, data, err := DecodeDataURI(u.String())
+	if err != nil {
+		return "", false
+	}
+	if mediaType, _, _ := strings.Cut(mime, ";"); !strings.EqualFold(strings.TrimSpace(mediaType), "text/html") {
+		return "", false
+	}
+	return string(data), true
+}
+
+// AddLocalizedDescription adds a DESCRIPTION in the language lang alongside any existing ones. Multiple DESCRIPTIONs are
+// not permitted by RFC5545 for most components, but are commonly used to provide translations. Use SetDescription for a
+// single description.
+func (cb *ComponentBase) AddLocalizedDescription(text string, lang string) {
+	cb.AddProperty(ComponentPropertyDescription, text, WithLanguage(lang))
+}
+
+// DescriptionFor returns the DESCRIPTION best matching the language lang, preferring an exact (case-insensitive) match,
+// then one of the same primary language such as "en" for "en-AU", then one without a LANGUAGE, then the first. Returns ""
+// if there is no DESCRIPTION.
+func (cb *ComponentBase) DescriptionFor(lang string) string {
+	descriptions := cb.GetProperties(ComponentPropertyDescription)
+	if len(descriptions) == 0 {
+		return ""
+	}
+	primary, _, _ := strings.Cut(lang, "-")
+	best, bestRank := descriptions[0].Value, 0
+	for _, p := range descriptions {
+		var rank int
+		l, err := p.parameterValue(ParameterLanguage)
+		lPrimary, _, _ := strings.Cut(l, "-")
+		switch {
+		case err == nil && strings.EqualFold(l, lang):
+			return p.Value
+		case err == nil && strings.EqualFold(lPrimary, primary):
+			rank = 2
+		case err != nil:
+			rank = 1
+		}
+		if rank > bestRank {
+			best, bestRank = p.Value, rank
+		}
+	}
+	return best
+}
+
 func (cb *ComponentBase) SetLocation(s string, params ...PropertyParameter) {
 	cb.SetProperty(ComponentPropertyLocation, s, params...)
 }
@@ -382,12 +773,25 @@ func (cb *ComponentBase) SetURL(s string, params ...PropertyParameter) {
 	cb.SetProperty(ComponentPropertyUrl, s, params...)
 }
 
+// SetOrganizer sets the ORGANIZER, adding a mailto: scheme to a bare email address. Values which already have a scheme,
+// such as urn:uuid: addresses used by some CalDAV servers, are kept as they are.
 func (cb *ComponentBase) SetOrganizer(s string, params ...PropertyParameter) {
-	if !strings.HasPrefix(s, "mailto:") {
-		s = "mailto:" + s
-	}
+	cb.SetOrganizerAddress(mailtoCalAddress(s), params...)
+}
 
-	cb.SetProperty(ComponentPropertyOrganizer, s, params...)
+// SetOrganizerAddress sets the ORGANIZER to the cal-address addr verbatim.
+func (cb *ComponentBase) SetOrganizerAddress(addr string, params ...PropertyParameter) {
+	cb.SetProperty(ComponentPropertyOrganizer, addr, params...)
+}
+
+var calAddressScheme = regexp.MustCompile("^[A-Za-z][A-Za-z0-9+.-]*:")
+
+// mailtoCalAddress returns s as a cal-address, adding a mailto: scheme unless it already has a scheme
+func mailtoCalAddress(s string) string {
+	if calAddressScheme.MatchString(s) {
+		return s
+	}
+	return "mailto:" + s
 }
 
 func (cb *ComponentBase) SetColor(s string, params ...PropertyParameter) {
@@ -398,6 +802,15 @@ func (cb *ComponentBase) SetClass(c Classification, params ...PropertyParameter)
 	cb.SetProperty(ComponentPropertyClass, string(c), params...)
 }
 
+// GetClass returns the CLASS of the component, defaulting to ClassificationPublic when absent as per the RFC.
+func (cb *ComponentBase) GetClass() Classification {
+	p := cb.GetProperty(ComponentPropertyClass)
+	if p == nil || p.Value == "" {
+		return ClassificationPublic
+	}
+	return Classification(strings.ToUpper(p.Value))
+}
+
 func (cb *ComponentBase) setPriority(p int, params ...PropertyParameter) {
 	cb.SetProperty(ComponentPropertyPriority, strconv.Itoa(p), params...)
 }
@@ -406,12 +819,9 @@ func (cb *ComponentBase) setResources(r string, params ...PropertyParameter) {
 	cb.SetProperty(ComponentPropertyResources, r, params...)
 }
 
+// AddAttendee adds an ATTENDEE, adding a mailto: scheme to a bare email address as SetOrganizer does.
 func (cb *ComponentBase) AddAttendee(s string, params ...PropertyParameter) {
-	if !strings.HasPrefix(s, "mailto:") {
-		s = "mailto:" + s
-	}
-
-	cb.AddProperty(ComponentPropertyAttendee, s, params...)
+	cb.AddProperty(ComponentPropertyAttendee, mailtoCalAddress(s), params...)
 }
 
 func (cb *ComponentBase) AddExdate(s string, params ...PropertyParameter) {
@@ -430,12 +840,36 @@ func (cb *ComponentBase) AddRrule(s string, params ...PropertyParameter) {
 	cb.AddProperty(ComponentPropertyRrule, s, params...)
 }
 
+// formatLikeStart formats t in the same form as DTSTART, being a date, a time in DTSTART's TZID, a floating time or a
+// UTC time, returning the value along with the parameters required to match.
+func (cb *ComponentBase) formatLikeStart(t time.Time) (string, []PropertyParameter) {
+	p := cb.GetProperty(ComponentPropertyDtStart)
+	if p == nil {
+		return t.UTC().Format(icalTimestampFormatUtc), nil
+	}
+	if p.GetValueType() == ValueDataTypeDate {
+		return t.Format(icalDateFormatLocal), []PropertyParameter{WithValue(string(ValueDataTypeDate))}
+	}
+	if tzid, ok := p.ICalParameters[string(ParameterTzid)]; ok && len(tzid) > 0 {
+		if loc, err := time.LoadLocation(tzid[0]); err == nil {
+			t = t.In(loc)
+		}
+		return t.Format(icalTimestampFormatLocal), []PropertyParameter{WithTZID(tzid[0])}
+	}
+	if strings.HasSuffix(p.Value, "Z") {
+		return t.UTC().Format(icalTimestampFormatUtc), nil
+	}
+	return t.Format(icalTimestampFormatLocal), nil
+}
+
 func (cb *ComponentBase) AddAttachment(s string, params ...PropertyParameter) {
 	cb.AddProperty(ComponentPropertyAttach, s, params...)
 }
 
-func (cb *ComponentBase) AddAttachmentURL(uri string, contentType string) {
-	cb.AddAttachment(uri, WithFmtType(contentType))
+// AddAttachmentURL adds an ATTACH property referencing uri. URI is the default value type for ATTACH, so VALUE=URI is
+// omitted unless explicitly requested by passing WithValue(string(ValueDataTypeUri)) in params.
+func (cb *ComponentBase) AddAttachmentURL(uri string, contentType string, params ...PropertyParameter) {
+	cb.AddAttachment(uri, append([]PropertyParameter{WithFmtType(contentType)}, params...)...)
 }
 
 func (cb *ComponentBase) AddAttachmentBinary(binary []byte, contentType string) {
@@ -444,6 +878,77 @@ func (cb *ComponentBase) AddAttachmentBinary(binary []byte, contentType string)
 	)
 }
 
+// AddAttachmentFromReader adds an inline binary ATTACH property streaming r straight into the base64 encoder. If more
+// than maxSize bytes can be read from r ErrorAttachmentTooLarge is returned and no property is added. A maxSize of 0 or
+// less disables the limit.
+func (cb *ComponentBase) AddAttachmentFromReader(r io.Reader, contentType string, maxSize int64) error {
+	if maxSize > 0 {
+		r = io.LimitReader(r, maxSize+1)
+	}
+	b := &strings.Builder{}
+	enc := base64.NewEncoder(base64.StdEncoding, b)
+	n, err := io.Copy(enc, r)
+	if err != nil {
+		return fmt.Errorf("reading attachment: %w", err)
+	}
+	if maxSize > 0 && n > maxSize {
+		return fmt.Errorf("%w: more than %d bytes", ErrorAttachmentTooLarge, maxSize)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encoding attachment: %w", err)
+	}
+	cb.AddAttachment(b.String(),
+		WithFmtType(contentType), WithEncoding("base64"), WithValue("binary"),
+	)
+	return nil
+}
+
+// AddDataURIAttachment adds an ATTACH property holding data inline as a base64 data: URI of type mimeType. Unlike
+// AddAttachmentBinary no ENCODING or VALUE parameters are needed, as the value is an ordinary URI.
+func (cb *ComponentBase) AddDataURIAttachment(mimeType string, data []byte, params ...PropertyParameter) {
+	uri := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+	cb.AddAttachmentURL(uri, mimeType, params...)
+}
+
+// DecodeDataURI returns the media type and content of a RFC2397 data: URI, such as an ATTACH value or an ALTREP, which
+// may be base64 or percent encoded. The media type defaults to "text/plain;charset=US-ASCII".
+func DecodeDataURI(value string) (mime string, data []byte, err error) {
+	if len(value) < len("data:") || !strings.EqualFold(value[:len("data:")], "data:") {
+		return "", nil, errors.New("not a data URI")
+	}
+	meta, content, ok := strings.Cut(value[len("data:"):], ",")
+	if !ok {
+		return "", nil, errors.New("data URI: missing ','")
+	}
+	isBase64 := false
+	if m, ok := strings.CutSuffix(meta, ";base64"); ok {
+		meta, isBase64 = m, true
+	}
+	mime = meta
+	if mime == "" || strings.HasPrefix(mime, ";") {
+		mime = "text/plain" + mime
+		if meta == "" {
+			mime = "text/plain;charset=US-ASCII"
+		}
+	}
+	if isBase64 {
+		unescaped, err := url.PathUnescape(content)
+		if err != nil {
+			return "", nil, fmt.Errorf("data URI: %w", err)
+		}
+		data, err = base64.StdEncoding.DecodeString(unescaped)
+		if err != nil {
+			return "", nil, fmt.Errorf("data URI: %w", err)
+		}
+		return mime, data, nil
+	}
+	unescaped, err := url.PathUnescape(content)
+	if err != nil {
+		return "", nil, fmt.Errorf("data URI: %w", err)
+	}
+	return mime, []byte(unescaped), nil
+}
+
 func (cb *ComponentBase) AddComment(s string, params ...PropertyParameter) {
 	cb.AddProperty(ComponentPropertyComment, s, params...)
 }
@@ -452,21 +957,67 @@ func (cb *ComponentBase) AddCategory(s string, params ...PropertyParameter) {
 	cb.AddProperty(ComponentPropertyCategories, s, params...)
 }
 
+// Rrules returns the value of each RRULE property as is.
+func (cb *ComponentBase) Rrules() []string {
+	return cb.propertyValues(ComponentPropertyRrule)
+}
+
+// Exrules returns the value of each EXRULE property as is.
+func (cb *ComponentBase) Exrules() []string {
+	return cb.propertyValues(ComponentPropertyExrule)
+}
+
+// Rdates returns the value of each RDATE property as is, which may each hold several comma separated dates.
+func (cb *ComponentBase) Rdates() []string {
+	return cb.propertyValues(ComponentPropertyRdate)
+}
+
+// Exdates returns the value of each EXDATE property as is, which may each hold several comma separated dates.
+func (cb *ComponentBase) Exdates() []string {
+	return cb.propertyValues(ComponentPropertyExdate)
+}
+
+func (cb *ComponentBase) propertyValues(componentProperty ComponentProperty) []string {
+	var r []string
+	for _, p := range cb.GetProperties(componentProperty) {
+		r = append(r, p.Value)
+	}
+	return r
+}
+
 type Attendee struct {
 	IANAProperty
 }
 
+// Email returns the attendee's address without its mailto: scheme, which is matched case-insensitively. Cal-addresses
+// with any other scheme, such as urn:uuid:, or none at all are returned unchanged, see CalAddress.
 func (p *Attendee) Email() string {
-	if strings.HasPrefix(p.Value, "mailto:") {
+	if len(p.Value) >= len("mailto:") && strings.EqualFold(p.Value[:len("mailto:")], "mailto:") {
 		return p.Value[len("mailto:"):]
 	}
 	return p.Value
 }
 
+// IsValid returns true if the attendee's cal-address can be delivered to, see ComponentBase.ValidateAttendees.
+func (p *Attendee) IsValid() bool {
+	return validateCalAddress(p.Value) == nil
+}
+
+// CalAddress returns the attendee's cal-address verbatim, whatever its scheme, unlike Email which removes mailto:.
+func (p *Attendee) CalAddress() string {
+	return p.Value
+}
+
 func (p *Attendee) ParticipationStatus() ParticipationStatus {
 	return ParticipationStatus(p.getPropertyFirst(ParameterParticipationStatus))
 }
 
+// ScheduleStatus returns the SCHEDULE-STATUS codes of the last delivery to the attendee, comma separated if there are
+// several, or "" if not set.
+func (p *Attendee) ScheduleStatus() string {
+	return strings.Join(p.getProperty(ParameterScheduleStatus), ",")
+}
+
 func (p *Attendee) getPropertyFirst(parameter Parameter) string {
 	vs := p.getProperty(parameter)
 	if len(vs) > 0 {
@@ -496,6 +1047,57 @@ func (cb *ComponentBase) Attendees() []*Attendee {
 	return r
 }
 
+// Contact is a CONTACT property, whose ALTREP, see IANAProperty.AltRep, often references a vCard for the contact
+type Contact struct {
+	IANAProperty
+}
+
+// Text returns the contact information, such as a name and phone number.
+func (p *Contact) Text() string {
+	return p.Value
+}
+
+// AddContact adds a CONTACT with the display text and, when vcardURI is not nil, an ALTREP referencing a vCard with the
+// full contact details as shown in RFC5545 section 3.8.4.2.
+func (cb *ComponentBase) AddContact(text string, vcardURI *url.URL, params ...PropertyParameter) {
+	if vcardURI != nil {
+		params = append(params, WithAlternativeRepresentation(vcardURI))
+	}
+	cb.AddProperty(ComponentPropertyContact, text, params...)
+}
+
+// Contacts returns the component's CONTACT properties.
+func (cb *ComponentBase) Contacts() []*Contact {
+	var r []*Contact
+	for i := range cb.Properties {
+		if cb.Properties[i].IANAToken == string(ComponentPropertyContact) {
+			r = append(r, &Contact{cb.Properties[i]})
+		}
+	}
+	return r
+}
+
+// RoleOf returns whether email is the event's ORGANIZER and the ATTENDEE with that address, if any. Addresses are compared
+// case-insensitively with any mailto: scheme removed.
+func (event *VEvent) RoleOf(email string) (isOrganizer bool, attendee *Attendee) {
+	email = normalizeCalAddress(email)
+	if p := event.GetProperty(ComponentPropertyOrganizer); p != nil {
+		isOrganizer = normalizeCalAddress(p.Value) == email
+	}
+	for _, a := range event.Attendees() {
+		if normalizeCalAddress(a.Value) == email {
+			return isOrganizer, a
+		}
+	}
+	return isOrganizer, nil
+}
+
+// normalizeCalAddress returns a cal-address or email address in lower case without a mailto: scheme
+func normalizeCalAddress(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	return strings.TrimPrefix(s, "mailto:")
+}
+
 func (cb *ComponentBase) Id() string {
 	p := cb.GetProperty(ComponentPropertyUniqueId)
 	if p != nil {
@@ -504,6 +1106,14 @@ func (cb *ComponentBase) Id() string {
 	return ""
 }
 
+func (cb *ComponentBase) recurrenceIdValue() string {
+	p := cb.GetProperty(ComponentPropertyRecurrenceId)
+	if p != nil {
+		return p.Value
+	}
+	return ""
+}
+
 func (cb *ComponentBase) addAlarm() *VAlarm {
 	a := &VAlarm{
 		ComponentBase: ComponentBase{},
@@ -516,6 +1126,32 @@ func (cb *ComponentBase) addVAlarm(a *VAlarm) {
 	cb.Components = append(cb.Components, a)
 }
 
+func (cb *ComponentBase) removeSubComponent(c Component) bool {
+	for i := range cb.Components {
+		if sameComponent(cb.Components[i], c) {
+			cb.Components = append(cb.Components[:i], cb.Components[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// sameComponent returns true if a and b are the same pointer, or for components which are not pointers, deeply equal.
+// Unlike == it does not panic on components from outside this package whose types are not comparable.
+func sameComponent(a, b Component) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Type() != vb.Type() {
+		return false
+	}
+	if va.Kind() == reflect.Pointer {
+		return va.Pointer() == vb.Pointer()
+	}
+	return reflect.DeepEqual(a, b)
+}
+
 func (cb *ComponentBase) alarms() []*VAlarm {
 	var r []*VAlarm
 	for i := range cb.Components {
@@ -535,11 +1171,61 @@ func (event *VEvent) SerializeTo(w io.Writer, serialConfig *SerializationConfigu
 	return event.ComponentBase.serializeThis(w, ComponentVEvent, serialConfig)
 }
 
+func (event *VEvent) ComponentType() ComponentType {
+	return ComponentVEvent
+}
+
 func (event *VEvent) Serialize(serialConfig *SerializationConfiguration) string {
 	s, _ := event.serialize(serialConfig)
 	return s
 }
 
+// SerializeE is Serialize but returns any error encountered while serializing, such as from a sub component, along with
+// the output up to the failure.
+func (event *VEvent) SerializeE(serialConfig *SerializationConfiguration) (string, error) {
+	return event.serialize(serialConfig)
+}
+
+// ToICS serializes just the event, without a VCALENDAR wrapper, accepting the same options as Calendar.Serialize. This
+// is useful for embedding an event in a larger document or assembling a calendar from pieces.
+func (event *VEvent) ToICS(ops ...any) (string, error) {
+	serialConfig, err := parseSerializeOps(ops)
+	if err != nil {
+		return "", err
+	}
+	return event.serialize(serialConfig)
+}
+
+// AsCalendar returns a minimal calendar with VERSION and PRODID containing a copy of the event, along with a VTIMEZONE
+// for each TZID it references, suitable for sharing the one event. Each VTIMEZONE is copied from the first of sources
+// defining it, typically the calendar the event came from, otherwise it is generated from the time zone database as
+// by Calendar.AddMinimalTimezones.
+func (event *VEvent) AsCalendar(sources ...*Calendar) *Calendar {
+	cal := NewCalendar()
+	cal.Components = append(cal.Components, CloneComponent(event))
+	var timezones []Component
+	for _, tzid := range cal.ReferencedTZIDs() {
+		if tz := findTimezone(tzid, sources); tz != nil {
+			timezones = append(timezones, CloneComponent(tz))
+		}
+	}
+	cal.Components = append(timezones, cal.Components...)
+	cal.AddMinimalTimezones()
+	return cal
+}
+
+// findTimezone returns the first VTIMEZONE with the TZID among the calendars, or nil if there is none
+func findTimezone(tzid string, calendars []*Calendar) *VTimezone {
+	for _, cal := range calendars {
+		for _, tz := range cal.Timezones() {
+			if p := tz.GetProperty(ComponentPropertyTzid); p != nil && p.Value == tzid {
+				return tz
+			}
+		}
+	}
+	return nil
+}
+
 func (event *VEvent) serialize(serialConfig *SerializationConfiguration) (string, error) {
 	b := &bytes.Buffer{}
 	err := event.ComponentBase.serializeThis(b, ComponentVEvent, serialConfig)
@@ -553,8 +1239,9 @@ func NewEvent(uniqueId string) *VEvent {
 	return e
 }
 
+// SetEndAt sets DTEND, removing any DURATION as the two are mutually exclusive.
 func (event *VEvent) SetEndAt(t time.Time, props ...PropertyParameter) {
-	event.SetProperty(ComponentPropertyDtEnd, t.UTC().Format(icalTimestampFormatUtc), props...)
+	event.ComponentBase.SetEndAt(t, props...)
 }
 
 func (event *VEvent) SetLastModifiedAt(t time.Time, props ...PropertyParameter) {
@@ -570,6 +1257,27 @@ func (event *VEvent) SetPriority(p int, params ...PropertyParameter) {
 	event.setPriority(p, params...)
 }
 
+// PriorityLabel groups PRIORITY as RFC5545 suggests: "HIGH" for 1-4, "MEDIUM" for 5, "LOW" for 6-9 and "UNDEFINED" for 0,
+// an absent PRIORITY or an invalid one.
+func (event *VEvent) PriorityLabel() string {
+	p := event.GetProperty(ComponentPropertyPriority)
+	if p == nil {
+		return "UNDEFINED"
+	}
+	priority, err := strconv.Atoi(strings.TrimSpace(p.Value))
+	switch {
+	case err != nil:
+		return "UNDEFINED"
+	case priority >= 1 && priority <= 4:
+		return "HIGH"
+	case priority == 5:
+		return "MEDIUM"
+	case priority >= 6 && priority <= 9:
+		return "LOW"
+	}
+	return "UNDEFINED"
+}
+
 func (event *VEvent) SetResources(r string, params ...PropertyParameter) {
 	event.setResources(r, params...)
 }
@@ -586,10 +1294,200 @@ func (event *VEvent) Alarms() []*VAlarm {
 	return event.alarms()
 }
 
+// RemoveSubComponent removes the given nested component, such as an alarm, returning true if it was found.
+func (event *VEvent) RemoveSubComponent(c Component) bool {
+	return event.removeSubComponent(c)
+}
+
+// FormatUntil formats t as the UNTIL of an RRULE for this event, which RFC5545 requires to match DTSTART: a date for all
+// day events, a floating time for floating events and otherwise a UTC time, even when DTSTART has a TZID.
+func (event *VEvent) FormatUntil(t time.Time) string {
+	p := event.GetProperty(ComponentPropertyDtStart)
+	switch {
+	case p == nil:
+	case p.GetValueType() == ValueDataTypeDate:
+		return t.Format(icalDateFormatLocal)
+	case event.isFloating(ComponentPropertyDtStart):
+		return t.Format(icalTimestampFormatLocal)
+	}
+	return t.UTC().Format(icalTimestampFormatUtc)
+}
+
+// AddExdateMatching excludes the instance starting at t from the recurring event, formatting the EXDATE with the same
+// value type and TZID as DTSTART so clients match it against the generated instances.
+func (event *VEvent) AddExdateMatching(t time.Time) {
+	v, params := event.formatLikeStart(t)
+	event.AddExdate(v, params...)
+}
+
+// AddRdateMatching adds an instance starting at t to the recurring event, formatting the RDATE with the same value type
+// and TZID as DTSTART.
+func (event *VEvent) AddRdateMatching(t time.Time) {
+	v, params := event.formatLikeStart(t)
+	event.AddRdate(v, params...)
+}
+
+// GetAllDayEndAt returns the DTEND as a date. An event with a date DTSTART and neither DTEND nor DURATION ends the day
+// after it starts, as RFC5545 section 3.6.1 gives it a duration of one day.
 func (event *VEvent) GetAllDayEndAt() (time.Time, error) {
+	if event.isAllDay() && !event.HasProperty(ComponentPropertyDtEnd) && !event.HasProperty(ComponentPropertyDuration) {
+		start, err := event.GetAllDayStartAt()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return start.AddDate(0, 0, 1), nil
+	}
 	return event.getTimeProp(ComponentPropertyDtEnd, true)
 }
 
+// EffectiveEnd returns the end of the event from DTEND, or when only a DURATION is present from DTSTART plus DURATION.
+// Without either, as RFC5545 section 3.6.1 describes, an event with a DATE DTSTART ends the next day and one with a
+// DATE-TIME DTSTART ends at DTSTART.
+func (event *VEvent) EffectiveEnd() (time.Time, error) {
+	if event.HasProperty(ComponentPropertyDtEnd) {
+		return event.GetEndAt()
+	}
+	if !event.HasProperty(ComponentPropertyDuration) {
+		start, err := event.GetStartAt()
+		if err != nil || !event.isAllDay() {
+			return start, err
+		}
+		return start.AddDate(0, 0, 1), nil
+	}
+	start, err := event.GetStartAt()
+	if err != nil {
+		return time.Time{}, err
+	}
+	d, err := event.GetDurationProperty()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return d.AddTo(start), nil
+}
+
+// UseDuration converts the event from DTEND to DURATION, replacing DTEND with the DURATION from DTSTART to it. Whole
+// days are expressed as nominal days so the end is kept across daylight saving changes. Events without a DTEND are left
+// as they are.
+func (event *VEvent) UseDuration() error {
+	if !event.HasProperty(ComponentPropertyDtEnd) {
+		return nil
+	}
+	start, err := event.GetStartAt()
+	if err != nil {
+		return err
+	}
+	end, err := event.GetEndAt()
+	if err != nil {
+		return err
+	}
+	if end.Before(start) {
+		return fmt.Errorf("%s %s is before %s %s", ComponentPropertyDtEnd, end, ComponentPropertyDtStart, start)
+	}
+	days := int(end.Sub(start) / (24 * time.Hour))
+	for days > 0 && start.AddDate(0, 0, days).After(end) {
+		days--
+	}
+	for !start.AddDate(0, 0, days+1).After(end) {
+		days++
+	}
+	rem := end.Sub(start.AddDate(0, 0, days))
+	d := Duration{
+		Days:    days,
+		Hours:   int(rem / time.Hour),
+		Minutes: int(rem % time.Hour / time.Minute),
+		Seconds: int(rem % time.Minute / time.Second),
+	}
+	event.RemoveProperty(ComponentPropertyDtEnd)
+	event.SetProperty(ComponentPropertyDuration, d.String())
+	return nil
+}
+
+// UseDtEnd converts the event from DURATION to DTEND, replacing DURATION with the DTEND it implies, formatted with the
+// same value type and TZID as DTSTART. Events without a DURATION are left as they are.
+func (event *VEvent) UseDtEnd() error {
+	if !event.HasProperty(ComponentPropertyDuration) {
+		return nil
+	}
+	start, err := event.GetStartAt()
+	if err != nil {
+		return err
+	}
+	d, err := event.GetDurationProperty()
+	if err != nil {
+		return err
+	}
+	v, params := event.formatLikeStart(d.AddTo(start))
+	event.RemoveProperty(ComponentPropertyDuration)
+	event.SetProperty(ComponentPropertyDtEnd, v, params...)
+	return nil
+}
+
+// IsAllDayEndInclusiveLikely reports whether an all day event appears to use its DTEND as the last day of the event,
+// rather than the RFC5545 exclusive day after. This is assumed when DTEND is a date not after DTSTART, or a date-time
+// at 23:59 as some producers write.
+func (event *VEvent) IsAllDayEndInclusiveLikely() bool {
+	_, ok := event.inclusiveAllDayEnd()
+	return ok
+}
+
+// inclusiveAllDayEnd returns the last day of an all day event whose DTEND appears to be inclusive
+func (event *VEvent) inclusiveAllDayEnd() (time.Time, bool) {
+	if !event.isAllDay() {
+		return time.Time{}, false
+	}
+	start, err := event.GetAllDayStartAt()
+	if err != nil {
+		return time.Time{}, false
+	}
+	p := event.GetProperty(ComponentPropertyDtEnd)
+	if p == nil {
+		return time.Time{}, false
+	}
+	if len(p.Value) == len(icalDateFormatLocal) {
+		end, err := p.parseTime(true)
+		if err != nil || end.After(start) {
+			return time.Time{}, false
+		}
+		return start, true
+	}
+	end, err := p.parseTime(false)
+	if err != nil || end.Hour() != 23 || end.Minute() != 59 {
+		return time.Time{}, false
+	}
+	return time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, start.Location()), true
+}
+
+// NormalizeAllDayEnd rewrites the DTEND of an all day event which is likely inclusive, see IsAllDayEndInclusiveLikely,
+// as the day after its last day. When assumeInclusive is set any DTEND date is taken to be the last day, for feeds
+// known to use inclusive ends. Returns true if DTEND was changed.
+func (event *VEvent) NormalizeAllDayEnd(assumeInclusive bool) bool {
+	last, ok := event.inclusiveAllDayEnd()
+	if p := event.GetProperty(ComponentPropertyDtEnd); !ok && assumeInclusive && event.isAllDay() && p != nil {
+		end, err := p.parseTime(true)
+		ok = err == nil
+		last = end
+	}
+	if !ok {
+		return false
+	}
+	event.SetAllDayEndAt(last.AddDate(0, 0, 1))
+	return true
+}
+
+// GetDurationProperty returns the event's own DURATION property, as opposed to the difference between its start and
+// end.
+func (event *VEvent) GetDurationProperty() (Duration, error) {
+	p := event.GetProperty(ComponentPropertyDuration)
+	if p == nil {
+		return Duration{}, fmt.Errorf("%w: %s", ErrorPropertyNotFound, ComponentPropertyDuration)
+	}
+	d, err := ParseDuration(p.Value)
+	if err != nil {
+		return Duration{}, fmt.Errorf("%s: %w", ComponentPropertyDuration, err)
+	}
+	return d, nil
+}
+
 type TimeTransparency string
 
 const (
@@ -601,6 +1499,26 @@ func (event *VEvent) SetTimeTransparency(v TimeTransparency, params ...PropertyP
 	event.SetProperty(ComponentPropertyTransp, string(v), params...)
 }
 
+// GetTimeTransparency returns the TRANSP of the event in upper case, defaulting to TransparencyOpaque when absent as per
+// the RFC.
+func (event *VEvent) GetTimeTransparency() TimeTransparency {
+	p := event.GetProperty(ComponentPropertyTransp)
+	if p == nil || p.Value == "" {
+		return TransparencyOpaque
+	}
+	return TimeTransparency(strings.ToUpper(p.Value))
+}
+
+// SetBusy marks the event as blocking time in free/busy lookups, setting TRANSP to OPAQUE.
+func (event *VEvent) SetBusy() {
+	event.SetTimeTransparency(TransparencyOpaque)
+}
+
+// SetFree marks the event as not blocking time in free/busy lookups, setting TRANSP to TRANSPARENT.
+func (event *VEvent) SetFree() {
+	event.SetTimeTransparency(TransparencyTransparent)
+}
+
 type VTodo struct {
 	ComponentBase
 }
@@ -609,11 +1527,20 @@ func (todo *VTodo) SerializeTo(w io.Writer, serialConfig *SerializationConfigura
 	return todo.ComponentBase.serializeThis(w, ComponentVTodo, serialConfig)
 }
 
+func (todo *VTodo) ComponentType() ComponentType {
+	return ComponentVTodo
+}
+
 func (todo *VTodo) Serialize(serialConfig *SerializationConfiguration) string {
 	s, _ := todo.serialize(serialConfig)
 	return s
 }
 
+// SerializeE is Serialize but returns any error encountered while serializing
+func (todo *VTodo) SerializeE(serialConfig *SerializationConfiguration) (string, error) {
+	return todo.serialize(serialConfig)
+}
+
 func (todo *VTodo) serialize(serialConfig *SerializationConfiguration) (string, error) {
 	b := &bytes.Buffer{}
 	err := todo.ComponentBase.serializeThis(b, ComponentVTodo, serialConfig)
@@ -673,6 +1600,22 @@ func (todo *VTodo) SetPercentComplete(p int, params ...PropertyParameter) {
 	todo.SetProperty(ComponentPropertyPercentComplete, strconv.Itoa(p), params...)
 }
 
+// Start marks the todo as being worked on from at, setting STATUS to IN-PROCESS and DTSTART. Any COMPLETED is removed,
+// as a todo which is in process is no longer complete.
+func (todo *VTodo) Start(at time.Time) {
+	todo.SetStatus(ObjectStatusInProcess)
+	todo.SetStartAt(at)
+	todo.RemoveProperty(ComponentPropertyCompleted)
+}
+
+// Reset returns the todo to not yet started, setting STATUS to NEEDS-ACTION and removing COMPLETED and
+// PERCENT-COMPLETE.
+func (todo *VTodo) Reset() {
+	todo.SetStatus(ObjectStatusNeedsAction)
+	todo.RemoveProperty(ComponentPropertyCompleted)
+	todo.RemoveProperty(ComponentPropertyPercentComplete)
+}
+
 func (todo *VTodo) SetGeo(lat interface{}, lng interface{}, params ...PropertyParameter) {
 	todo.setGeo(lat, lng, params...)
 }
@@ -717,6 +1660,11 @@ func (todo *VTodo) Alarms() []*VAlarm {
 	return todo.alarms()
 }
 
+// RemoveSubComponent removes the given nested component, such as an alarm, returning true if it was found.
+func (todo *VTodo) RemoveSubComponent(c Component) bool {
+	return todo.removeSubComponent(c)
+}
+
 func (todo *VTodo) GetDueAt() (time.Time, error) {
 	return todo.getTimeProp(ComponentPropertyDue, false)
 }
@@ -725,6 +1673,14 @@ func (todo *VTodo) GetAllDayDueAt() (time.Time, error) {
 	return todo.getTimeProp(ComponentPropertyDue, true)
 }
 
+func (todo *VTodo) GetCompletedAt() (time.Time, error) {
+	return todo.getTimeProp(ComponentPropertyCompleted, false)
+}
+
+func (todo *VTodo) GetAllDayCompletedAt() (time.Time, error) {
+	return todo.getTimeProp(ComponentPropertyCompleted, true)
+}
+
 type VJournal struct {
 	ComponentBase
 }
@@ -733,11 +1689,20 @@ func (journal *VJournal) SerializeTo(w io.Writer, serialConfig *SerializationCon
 	return journal.ComponentBase.serializeThis(w, ComponentVJournal, serialConfig)
 }
 
+func (journal *VJournal) ComponentType() ComponentType {
+	return ComponentVJournal
+}
+
 func (journal *VJournal) Serialize(serialConfig *SerializationConfiguration) string {
 	s, _ := journal.serialize(serialConfig)
 	return s
 }
 
+// SerializeE is Serialize but returns any error encountered while serializing
+func (journal *VJournal) SerializeE(serialConfig *SerializationConfiguration) (string, error) {
+	return journal.serialize(serialConfig)
+}
+
 func (journal *VJournal) serialize(serialConfig *SerializationConfiguration) (string, error) {
 	b := &bytes.Buffer{}
 	err := journal.ComponentBase.serializeThis(b, ComponentVJournal, serialConfig)
@@ -784,6 +1749,11 @@ func (busy *VBusy) Serialize(serialConfig *SerializationConfiguration) string {
 	return s
 }
 
+// SerializeE is Serialize but returns any error encountered while serializing
+func (busy *VBusy) SerializeE(serialConfig *SerializationConfiguration) (string, error) {
+	return busy.serialize(serialConfig)
+}
+
 func (busy *VBusy) serialize(serialConfig *SerializationConfiguration) (string, error) {
 	b := &bytes.Buffer{}
 	err := busy.ComponentBase.serializeThis(b, ComponentVFreeBusy, serialConfig)
@@ -797,6 +1767,71 @@ func (busy *VBusy) SerializeTo(w io.Writer, serialConfig *SerializationConfigura
 	return busy.ComponentBase.serializeThis(w, ComponentVFreeBusy, serialConfig)
 }
 
+func (busy *VBusy) ComponentType() ComponentType {
+	return ComponentVFreeBusy
+}
+
+// Period is a PERIOD value, such as one of the periods of a FREEBUSY property along with its FBTYPE
+type Period struct {
+	Start time.Time
+	End   time.Time
+	// Type is the FBTYPE of a FREEBUSY period, which defaults to BUSY
+	Type FreeBusyTimeType
+}
+
+// FreeBusy returns every period of every FREEBUSY property in order, splitting properties listing several periods
+// separated by commas. A period given as a start and duration has its End calculated.
+func (busy *VBusy) FreeBusy() ([]Period, error) {
+	var r []Period
+	for _, p := range busy.GetProperties(ComponentPropertyFreebusy) {
+		fbType := FreeBusyTimeTypeBusy
+		if v, err := p.parameterValue(ParameterFbtype); err == nil {
+			fbType = FreeBusyTimeType(strings.ToUpper(v))
+		}
+		periods, err := p.parsePeriods()
+		if err != nil {
+			return nil, err
+		}
+		for _, period := range periods {
+			period.Type = fbType
+			r = append(r, period)
+		}
+	}
+	return r, nil
+}
+
+// parsePeriods parses the comma separated PERIOD values of the property, each a start and either an end or a duration
+func (bp *BaseProperty) parsePeriods() ([]Period, error) {
+	var r []Period
+	for _, v := range strings.Split(bp.Value, ",") {
+		start, end, ok := strings.Cut(v, "/")
+		if !ok {
+			return nil, fmt.Errorf("%s: period %q has no end or duration", bp.IANAToken, v)
+		}
+		vp := *bp
+		vp.Value = start
+		period := Period{}
+		var err error
+		if period.Start, err = vp.parseTime(false); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(strings.TrimLeft(end, "+-"), "P") {
+			d, err := ParseDuration(end)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", bp.IANAToken, err)
+			}
+			period.End = d.AddTo(period.Start)
+		} else {
+			vp.Value = end
+			if period.End, err = vp.parseTime(false); err != nil {
+				return nil, err
+			}
+		}
+		r = append(r, period)
+	}
+	return r, nil
+}
+
 func NewBusy(uniqueId string) *VBusy {
 	e := &VBusy{
 		NewComponent(uniqueId),
@@ -834,6 +1869,11 @@ func (timezone *VTimezone) Serialize(serialConfig *SerializationConfiguration) s
 	return s
 }
 
+// SerializeE is Serialize but returns any error encountered while serializing
+func (timezone *VTimezone) SerializeE(serialConfig *SerializationConfiguration) (string, error) {
+	return timezone.serialize(serialConfig)
+}
+
 func (timezone *VTimezone) serialize(serialConfig *SerializationConfiguration) (string, error) {
 	b := &bytes.Buffer{}
 	err := timezone.ComponentBase.serializeThis(b, ComponentVTimezone, serialConfig)
@@ -847,12 +1887,22 @@ func (timezone *VTimezone) SerializeTo(w io.Writer, serialConfig *SerializationC
 	return timezone.ComponentBase.serializeThis(w, ComponentVTimezone, serialConfig)
 }
 
+func (timezone *VTimezone) ComponentType() ComponentType {
+	return ComponentVTimezone
+}
+
 func (timezone *VTimezone) AddStandard() *Standard {
 	e := NewStandard()
 	timezone.Components = append(timezone.Components, e)
 	return e
 }
 
+func (timezone *VTimezone) AddDaylight() *Daylight {
+	e := NewDaylight()
+	timezone.Components = append(timezone.Components, e)
+	return e
+}
+
 func NewTimezone(tzId string) *VTimezone {
 	e := &VTimezone{
 		ComponentBase{
@@ -894,6 +1944,11 @@ func (c *VAlarm) Serialize(serialConfig *SerializationConfiguration) string {
 	return s
 }
 
+// SerializeE is Serialize but returns any error encountered while serializing
+func (c *VAlarm) SerializeE(serialConfig *SerializationConfiguration) (string, error) {
+	return c.serialize(serialConfig)
+}
+
 func (c *VAlarm) serialize(serialConfig *SerializationConfiguration) (string, error) {
 	b := &bytes.Buffer{}
 	err := c.ComponentBase.serializeThis(b, ComponentVAlarm, serialConfig)
@@ -907,6 +1962,10 @@ func (c *VAlarm) SerializeTo(w io.Writer, serialConfig *SerializationConfigurati
 	return c.ComponentBase.serializeThis(w, ComponentVAlarm, serialConfig)
 }
 
+func (c *VAlarm) ComponentType() ComponentType {
+	return ComponentVAlarm
+}
+
 func NewAlarm(tzId string) *VAlarm {
 	// Todo How did this come about?
 	e := &VAlarm{}
@@ -936,6 +1995,40 @@ func (c *VAlarm) SetTrigger(s string, params ...PropertyParameter) {
 	c.SetProperty(ComponentPropertyTrigger, s, params...)
 }
 
+// TriggerTimeFor returns the absolute time the alarm fires for the occurrence of event starting at occurrence. Relative
+// triggers are applied to the start of the occurrence unless RELATED=END is set, in which case they are applied to the
+// end of the occurrence, derived from the length of event.
+func (c *VAlarm) TriggerTimeFor(event *VEvent, occurrence time.Time) (time.Time, error) {
+	trigger := c.GetProperty(ComponentPropertyTrigger)
+	if trigger == nil {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrorPropertyNotFound, ComponentPropertyTrigger)
+	}
+	if trigger.GetValueType() == ValueDataTypeDateTime {
+		return c.getTimeProp(ComponentPropertyTrigger, false)
+	}
+	d, err := ParseDuration(trigger.Value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("trigger: %w", err)
+	}
+	related, _ := trigger.parameterValue(ParameterRelated)
+	switch strings.ToUpper(related) {
+	case "", "START":
+		return d.AddTo(occurrence), nil
+	case "END":
+		start, err := event.GetStartAt()
+		if err != nil {
+			return time.Time{}, err
+		}
+		end, err := event.EffectiveEnd()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return d.AddTo(occurrence.Add(end.Sub(start))), nil
+	default:
+		return time.Time{}, fmt.Errorf("unsupported trigger %s %q", ParameterRelated, related)
+	}
+}
+
 type Standard struct {
 	ComponentBase
 }
@@ -952,6 +2045,11 @@ func (standard *Standard) Serialize(serialConfig *SerializationConfiguration) st
 	return s
 }
 
+// SerializeE is Serialize but returns any error encountered while serializing
+func (standard *Standard) SerializeE(serialConfig *SerializationConfiguration) (string, error) {
+	return standard.serialize(serialConfig)
+}
+
 func (standard *Standard) serialize(serialConfig *SerializationConfiguration) (string, error) {
 	b := &bytes.Buffer{}
 	err := standard.ComponentBase.serializeThis(b, ComponentStandard, serialConfig)
@@ -965,15 +2063,31 @@ func (standard *Standard) SerializeTo(w io.Writer, serialConfig *SerializationCo
 	return standard.ComponentBase.serializeThis(w, ComponentStandard, serialConfig)
 }
 
+func (standard *Standard) ComponentType() ComponentType {
+	return ComponentStandard
+}
+
 type Daylight struct {
 	ComponentBase
 }
 
+func NewDaylight() *Daylight {
+	e := &Daylight{
+		ComponentBase{},
+	}
+	return e
+}
+
 func (daylight *Daylight) Serialize(serialConfig *SerializationConfiguration) string {
 	s, _ := daylight.serialize(serialConfig)
 	return s
 }
 
+// SerializeE is Serialize but returns any error encountered while serializing
+func (daylight *Daylight) SerializeE(serialConfig *SerializationConfiguration) (string, error) {
+	return daylight.serialize(serialConfig)
+}
+
 func (daylight *Daylight) serialize(serialConfig *SerializationConfiguration) (string, error) {
 	b := &bytes.Buffer{}
 	err := daylight.ComponentBase.serializeThis(b, ComponentDaylight, serialConfig)
@@ -987,6 +2101,10 @@ func (daylight *Daylight) SerializeTo(w io.Writer, serialConfig *SerializationCo
 	return daylight.ComponentBase.serializeThis(w, ComponentDaylight, serialConfig)
 }
 
+func (daylight *Daylight) ComponentType() ComponentType {
+	return ComponentDaylight
+}
+
 type GeneralComponent struct {
 	ComponentBase
 	Token string
@@ -997,6 +2115,11 @@ func (general *GeneralComponent) Serialize(serialConfig *SerializationConfigurat
 	return s
 }
 
+// SerializeE is Serialize but returns any error encountered while serializing
+func (general *GeneralComponent) SerializeE(serialConfig *SerializationConfiguration) (string, error) {
+	return general.serialize(serialConfig)
+}
+
 func (general *GeneralComponent) serialize(serialConfig *SerializationConfiguration) (string, error) {
 	b := &bytes.Buffer{}
 	err := general.ComponentBase.serializeThis(b, ComponentType(general.Token), serialConfig)
@@ -1010,6 +2133,11 @@ func (general *GeneralComponent) SerializeTo(w io.Writer, serialConfig *Serializ
 	return general.ComponentBase.serializeThis(w, ComponentType(general.Token), serialConfig)
 }
 
+// ComponentType returns the token the component was parsed with, such as a vendor specific X- component.
+func (general *GeneralComponent) ComponentType() ComponentType {
+	return ComponentType(general.Token)
+}
+
 func GeneralParseComponent(cs *CalendarStream, startLine *BaseProperty) (Component, error) {
 	var co Component
 	var err error
@@ -1033,7 +2161,15 @@ func GeneralParseComponent(cs *CalendarStream, startLine *BaseProperty) (Compone
 	case ComponentDaylight:
 		co, err = ParseDaylightWithError(cs, startLine)
 	default:
-		co, err = ParseGeneralComponentWithError(cs, startLine)
+		if cs.parseConfig.Strict && !isExtensionName(startLine.Value) {
+			return nil, fmt.Errorf("%w: %s", ErrorUnknownComponent, startLine.Value)
+		}
+		var general *GeneralComponent
+		general, err = ParseGeneralComponentWithError(cs, startLine)
+		if err != nil || cs.parseConfig.DropUnknownComponents {
+			return nil, err
+		}
+		co = general
 	}
 	return co, err
 }
@@ -1199,7 +2335,7 @@ func ParseComponent(cs *CalendarStream, startLine *BaseProperty) (ComponentBase,
 		if l == nil || len(*l) == 0 {
 			continue
 		}
-		line, err := ParseProperty(*l)
+		line, err := parseProperty(*l, cs.parseConfig.PreserveRawValues)
 		if err != nil {
 			return cb, fmt.Errorf("parsing component property %d: %w", ln, err)
 		}
@@ -1223,7 +2359,13 @@ func ParseComponent(cs *CalendarStream, startLine *BaseProperty) (ComponentBase,
 				cb.Components = append(cb.Components, co)
 			}
 		default: // TODO put in all the supported types for type switching etc.
-			cb.Properties = append(cb.Properties, IANAProperty{*line})
+			keep, err := cs.keepProperty(line)
+			if err != nil {
+				return cb, fmt.Errorf("parsing component property %d: %w", ln, err)
+			}
+			if keep {
+				cb.Properties = append(cb.Properties, IANAProperty{*line})
+			}
 		}
 	}
 	return cb, errors.New("ran out of lines")
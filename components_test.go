@@ -1,6 +1,9 @@
 package ics
 
 import (
+	"errors"
+	"io"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -160,6 +163,33 @@ func TestSetMailtoPrefix(t *testing.T) {
 	}
 }
 
+func TestNonMailtoCalAddress(t *testing.T) {
+	e := NewEvent("test-cal-address")
+
+	e.SetOrganizer("MAILTO:org@provider.com")
+	assert.Equal(t, "MAILTO:org@provider.com", e.GetProperty(ComponentPropertyOrganizer).Value)
+	e.SetOrganizer("urn:uuid:0d2cdb24-2b34-4e8a-9d0a-6f0e8b6d1f6c")
+	assert.Equal(t, "urn:uuid:0d2cdb24-2b34-4e8a-9d0a-6f0e8b6d1f6c", e.GetProperty(ComponentPropertyOrganizer).Value)
+	e.SetOrganizerAddress("org@provider.com", WithCN("Org"))
+	assert.Equal(t, "org@provider.com", e.GetProperty(ComponentPropertyOrganizer).Value)
+
+	e.AddAttendee("urn:uuid:6f3a1d2e-1111-4c3b-8f8e-2a1b3c4d5e6f")
+	e.AddAttendee("MAILTO:att@provider.com")
+	e.AddProperty(ComponentPropertyAttendee, "att2@provider.com")
+	attendees := e.Attendees()
+	if !assert.Len(t, attendees, 3) {
+		return
+	}
+	assert.Equal(t, "urn:uuid:6f3a1d2e-1111-4c3b-8f8e-2a1b3c4d5e6f", attendees[0].Email())
+	assert.Equal(t, "urn:uuid:6f3a1d2e-1111-4c3b-8f8e-2a1b3c4d5e6f", attendees[0].CalAddress())
+	assert.True(t, attendees[0].IsValid())
+	assert.Equal(t, "MAILTO:att@provider.com", attendees[1].CalAddress())
+	assert.Equal(t, "att@provider.com", attendees[1].Email())
+	assert.True(t, attendees[1].IsValid())
+	assert.Equal(t, "att2@provider.com", attendees[2].Email())
+	assert.False(t, attendees[2].IsValid())
+}
+
 func TestRemoveProperty(t *testing.T) {
 	testCases := []struct {
 		name   string
@@ -190,3 +220,935 @@ END:VTODO
 		})
 	}
 }
+
+func TestAddAttachmentURL(t *testing.T) {
+	testCases := []struct {
+		name   string
+		params []PropertyParameter
+		output string
+	}{
+		{
+			name: "default omits value",
+			output: `BEGIN:VEVENT
+UID:test-attachment
+ATTACH;FMTTYPE=application/pdf:https://example.com/file.pdf
+END:VEVENT
+`,
+		},
+		{
+			name:   "explicit uri value",
+			params: []PropertyParameter{WithValue(string(ValueDataTypeUri))},
+			output: `BEGIN:VEVENT
+UID:test-attachment
+ATTACH;FMTTYPE=application/pdf;VALUE=URI:https://example.com/file.pdf
+END:VEVENT
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := NewEvent("test-attachment")
+			e.AddAttachmentURL("https://example.com/file.pdf", "application/pdf", tc.params...)
+
+			text := strings.ReplaceAll(e.Serialize(defaultSerializationOptions()), "\r\n", "\n")
+
+			assert.Equal(t, tc.output, text)
+		})
+	}
+}
+
+func TestAddAttachmentFromReader(t *testing.T) {
+	e := NewEvent("test-attachment")
+	err := e.AddAttachmentFromReader(strings.NewReader("hello world"), "text/plain", 11)
+	assert.NoError(t, err)
+	p := e.GetProperty(ComponentPropertyAttach)
+	if assert.NotNil(t, p) {
+		assert.Equal(t, "aGVsbG8gd29ybGQ=", p.Value)
+		assert.Equal(t, []string{"base64"}, p.ICalParameters[string(ParameterEncoding)])
+	}
+
+	e = NewEvent("test-attachment")
+	err = e.AddAttachmentFromReader(strings.NewReader("hello world"), "text/plain", 10)
+	assert.ErrorIs(t, err, ErrorAttachmentTooLarge)
+	assert.False(t, e.HasProperty(ComponentPropertyAttach))
+}
+
+func TestComponentType(t *testing.T) {
+	testCases := []struct {
+		component Component
+		expected  ComponentType
+	}{
+		{NewEvent("a"), ComponentVEvent},
+		{NewTodo("a"), ComponentVTodo},
+		{NewJournal("a"), ComponentVJournal},
+		{NewBusy("a"), ComponentVFreeBusy},
+		{NewTimezone("a"), ComponentVTimezone},
+		{&VAlarm{}, ComponentVAlarm},
+		{NewStandard(), ComponentStandard},
+		{&Daylight{}, ComponentDaylight},
+		{&GeneralComponent{Token: "X-VENDOR"}, ComponentType("X-VENDOR")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.expected), func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.component.ComponentType())
+		})
+	}
+}
+
+func TestRemoveSubComponent(t *testing.T) {
+	e := NewEvent("test-subcomponents")
+	a1 := e.AddAlarm()
+	a2 := e.AddAlarm()
+	assert.Len(t, e.SubComponents(), 2)
+
+	assert.True(t, e.RemoveSubComponent(a1))
+	assert.Equal(t, []*VAlarm{a2}, e.Alarms())
+	assert.False(t, e.RemoveSubComponent(a1))
+
+	assert.True(t, e.RemoveSubComponent(a2))
+	assert.Empty(t, e.SubComponents())
+
+	e.Components = append(e.Components, taggedComponent{tags: []string{"a"}})
+	e.AddAlarm()
+	assert.False(t, e.RemoveSubComponent(taggedComponent{tags: []string{"b"}}))
+	assert.True(t, e.RemoveSubComponent(taggedComponent{tags: []string{"a"}}))
+	assert.Len(t, e.SubComponents(), 1)
+}
+
+// taggedComponent is a Component from outside the package whose type is not comparable with ==
+type taggedComponent struct {
+	failingComponent
+	tags []string
+}
+
+func TestPropertyMap(t *testing.T) {
+	e := NewEvent("test-property-map")
+	e.SetSummary("Summary", WithCN("ignored"))
+	e.AddCategory("A")
+	e.AddCategory("B")
+
+	assert.Equal(t, map[ComponentProperty][]string{
+		ComponentPropertyUniqueId:   {"test-property-map"},
+		ComponentPropertySummary:    {"Summary"},
+		ComponentPropertyCategories: {"A", "B"},
+	}, e.PropertyMap())
+}
+
+func TestTriggerTimeFor(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	e := NewEvent("test-trigger")
+	e.SetStartAt(start)
+	e.SetEndAt(start.Add(time.Hour))
+	occurrence := start.AddDate(0, 0, 7)
+
+	testCases := []struct {
+		name     string
+		trigger  string
+		params   []PropertyParameter
+		expected time.Time
+	}{
+		{name: "default related start", trigger: "-PT15M", expected: occurrence.Add(-15 * time.Minute)},
+		{name: "related start", trigger: "-PT15M", params: []PropertyParameter{&KeyValues{Key: string(ParameterRelated), Value: []string{"START"}}}, expected: occurrence.Add(-15 * time.Minute)},
+		{name: "related end", trigger: "PT5M", params: []PropertyParameter{&KeyValues{Key: string(ParameterRelated), Value: []string{"END"}}}, expected: occurrence.Add(65 * time.Minute)},
+		{name: "absolute", trigger: "20240301T080000Z", params: []PropertyParameter{WithValue(string(ValueDataTypeDateTime))}, expected: time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := e.AddAlarm()
+			a.SetTrigger(tc.trigger, tc.params...)
+			got, err := a.TriggerTimeFor(e, occurrence)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+
+	durationEvent := NewEvent("test-trigger-duration")
+	durationEvent.SetStartAt(start)
+	durationEvent.SetProperty(ComponentPropertyDuration, "PT30M")
+	a := durationEvent.AddAlarm()
+	a.SetTrigger("PT0S", &KeyValues{Key: string(ParameterRelated), Value: []string{"END"}})
+	got, err := a.TriggerTimeFor(durationEvent, start)
+	assert.NoError(t, err)
+	assert.Equal(t, start.Add(30*time.Minute), got)
+}
+
+func TestSetRecurrenceID(t *testing.T) {
+	e := NewEvent("test-recurrence-id")
+	e.SetRecurrenceID(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), WithRange(string(RecurrenceRangeThisAndFuture)))
+
+	text := strings.ReplaceAll(e.Serialize(defaultSerializationOptions()), "\r\n", "\n")
+
+	assert.Equal(t, `BEGIN:VEVENT
+UID:test-recurrence-id
+RECURRENCE-ID;RANGE=THISANDFUTURE:20240301T090000Z
+END:VEVENT
+`, text)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestSerializeE(t *testing.T) {
+	e := NewEvent("test-serialize-e")
+	s, err := e.SerializeE(defaultSerializationOptions())
+	assert.NoError(t, err)
+	assert.Equal(t, e.Serialize(defaultSerializationOptions()), s)
+
+	err = e.SerializeTo(failingWriter{}, defaultSerializationOptions())
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+
+	e.Components = append(e.Components, failingComponent{})
+	s, err = e.SerializeE(defaultSerializationOptions())
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	assert.Equal(t, "BEGIN:VEVENT\nUID:test-serialize-e\n", s)
+}
+
+// failingComponent is a Component from outside the package which fails to serialize
+type failingComponent struct{}
+
+func (failingComponent) UnknownPropertiesIANAProperties() []IANAProperty { return nil }
+
+func (failingComponent) SubComponents() []Component { return nil }
+
+func (failingComponent) SerializeTo(io.Writer, *SerializationConfiguration) error {
+	return io.ErrUnexpectedEOF
+}
+
+func (failingComponent) ComponentType() ComponentType { return "X-FAILING" }
+
+func TestSerializeCanonicalPropertyOrder(t *testing.T) {
+	e := NewEvent("test-order")
+	e.AddProperty("X-VENDOR", "1")
+	e.SetSummary("Summary")
+	e.SetDtStampTime(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	e.SetStartAt(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC))
+
+	serialConfig := defaultSerializationOptions()
+	serialConfig.CanonicalPropertyOrder = true
+	text := strings.ReplaceAll(e.Serialize(serialConfig), "\r\n", "\n")
+
+	assert.Equal(t, `BEGIN:VEVENT
+UID:test-order
+DTSTAMP:20240301T090000Z
+DTSTART:20240301T100000Z
+SUMMARY:Summary
+X-VENDOR:1
+END:VEVENT
+`, text)
+	assert.Equal(t, "X-VENDOR", e.Properties[1].IANAToken)
+}
+
+func TestEffectiveEnd(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	e := NewEvent("test-effective-end")
+	_, err := e.EffectiveEnd()
+	assert.ErrorIs(t, err, ErrorPropertyNotFound)
+
+	e.SetStartAt(start)
+	got, err := e.EffectiveEnd()
+	assert.NoError(t, err)
+	assert.Equal(t, start, got)
+	_, err = e.GetEndAt()
+	assert.ErrorIs(t, err, ErrorPropertyNotFound)
+
+	e.SetProperty(ComponentPropertyDuration, "PT45M")
+	got, err = e.EffectiveEnd()
+	assert.NoError(t, err)
+	assert.Equal(t, start.Add(45*time.Minute), got)
+
+	e.RemoveProperty(ComponentPropertyDuration)
+	e.SetEndAt(start.Add(time.Hour))
+	got, err = e.EffectiveEnd()
+	assert.NoError(t, err)
+	assert.Equal(t, start.Add(time.Hour), got)
+}
+
+func TestSetEndAtRemovesDuration(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	e := NewEvent("test-exclusive")
+	e.SetStartAt(start)
+	e.SetProperty(ComponentPropertyDuration, "PT1H")
+	e.SetEndAt(start.Add(time.Hour))
+	assert.False(t, e.HasProperty(ComponentPropertyDuration))
+	assert.Nil(t, ComponentPropertyDtEnd.Exclusive(e))
+
+	e.RemoveProperty(ComponentPropertyDtEnd)
+	e.SetProperty(ComponentPropertyDuration, "PT1H")
+	assert.NoError(t, e.SetDuration(2*time.Hour))
+	assert.False(t, e.HasProperty(ComponentPropertyDuration))
+	end, err := e.GetEndAt()
+	assert.NoError(t, err)
+	assert.Equal(t, start.Add(2*time.Hour), end)
+}
+
+func TestGetClass(t *testing.T) {
+	e := NewEvent("test-class")
+	assert.Equal(t, ClassificationPublic, e.GetClass())
+	e.SetClass(ClassificationPrivate)
+	assert.Equal(t, ClassificationPrivate, e.GetClass())
+	e.SetProperty(ComponentPropertyClass, "confidential")
+	assert.Equal(t, ClassificationConfidential, e.GetClass())
+}
+
+func TestPriorityLabel(t *testing.T) {
+	e := NewEvent("test-priority")
+	assert.Equal(t, "UNDEFINED", e.PriorityLabel())
+	for priority, expected := range map[int]string{0: "UNDEFINED", 1: "HIGH", 4: "HIGH", 5: "MEDIUM", 6: "LOW", 9: "LOW", 10: "UNDEFINED"} {
+		e.SetPriority(priority)
+		assert.Equal(t, expected, e.PriorityLabel(), "priority %d", priority)
+	}
+	e.SetProperty(ComponentPropertyPriority, "high")
+	assert.Equal(t, "UNDEFINED", e.PriorityLabel())
+}
+
+func TestSetDescriptionRich(t *testing.T) {
+	altrep, err := url.Parse("https://example.com/d.html")
+	if !assert.NoError(t, err) {
+		return
+	}
+	e := NewEvent("test-rich-description")
+	_, ok := e.DescriptionAltRep()
+	assert.False(t, ok)
+
+	e.SetDescriptionRich("Plain, txt", altrep, "en")
+	text := strings.ReplaceAll(e.Serialize(defaultSerializationOptions()), "\r\n", "\n")
+	assert.Contains(t, text, "DESCRIPTION;ALTREP=\"https://example.com/d.html\";LANGUAGE=en:Plain\\, txt\n")
+
+	parsed, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nVERSION:2.0\n" + text + "END:VCALENDAR\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	got, ok := parsed.Events()[0].DescriptionAltRep()
+	assert.True(t, ok)
+	assert.Equal(t, altrep.String(), got.String())
+}
+
+func TestAddPropertyP(t *testing.T) {
+	e := NewEvent("test-add-property-p")
+	p := e.AddPropertyP(ComponentPropertyAttendee, "mailto:a@example.com", WithCN("A"))
+	p.ICalParameters[string(ParameterRole)] = []string{string(ParticipationRoleChair)}
+	got := e.GetProperty(ComponentPropertyAttendee)
+	assert.Equal(t, []string{"A"}, got.ICalParameters[string(ParameterCn)])
+	assert.Equal(t, []string{"CHAIR"}, got.ICalParameters[string(ParameterRole)])
+}
+
+func TestTimesTruncatedToSeconds(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 999999999, time.UTC)
+	e := NewEvent("test-truncation")
+	e.SetStartAt(start)
+	e.SetEndAt(start.Add(time.Hour))
+	assert.Equal(t, "20240102T030405Z", e.GetProperty(ComponentPropertyDtStart).Value)
+	got, err := e.GetStartAt()
+	assert.NoError(t, err)
+	assert.False(t, got.Equal(start))
+	assert.True(t, got.Equal(start.Truncate(time.Second)))
+	end, err := e.GetEndAt()
+	assert.NoError(t, err)
+	assert.True(t, end.Equal(start.Add(time.Hour).Truncate(time.Second)))
+}
+
+func TestGetDurationProperty(t *testing.T) {
+	e := NewEvent("test-duration-property")
+	_, err := e.GetDurationProperty()
+	assert.ErrorIs(t, err, ErrorPropertyNotFound)
+
+	e.SetProperty(ComponentPropertyDuration, "P1DT2H")
+	d, err := e.GetDurationProperty()
+	assert.NoError(t, err)
+	assert.Equal(t, Duration{Days: 1, Hours: 2}, d)
+
+	e.SetProperty(ComponentPropertyDuration, "1 hour")
+	_, err = e.GetDurationProperty()
+	assert.Error(t, err)
+}
+
+func TestNormalizeAllDayEnd(t *testing.T) {
+	tests := []struct {
+		name            string
+		dtend           string
+		assumeInclusive bool
+		likely          bool
+		expected        string
+	}{
+		{name: "exclusive", dtend: "DTEND;VALUE=DATE:20240103", expected: "20240103"},
+		{name: "same day", dtend: "DTEND;VALUE=DATE:20240101", likely: true, expected: "20240102"},
+		{name: "end of day", dtend: "DTEND:20240102T235900", likely: true, expected: "20240103"},
+		{name: "assumed inclusive", dtend: "DTEND;VALUE=DATE:20240103", assumeInclusive: true, expected: "20240104"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nDTSTART;VALUE=DATE:20240101\n" + tt.dtend + "\nEND:VEVENT\nEND:VCALENDAR\n"))
+			if !assert.NoError(t, err) {
+				return
+			}
+			e := cal.Events()[0]
+			assert.Equal(t, tt.likely, e.IsAllDayEndInclusiveLikely())
+			assert.Equal(t, tt.likely || tt.assumeInclusive, e.NormalizeAllDayEnd(tt.assumeInclusive))
+			assert.Equal(t, tt.expected, e.GetProperty(ComponentPropertyDtEnd).Value)
+		})
+	}
+
+	cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nDTSTART;VALUE=DATE:20240101\nEND:VEVENT\nEND:VCALENDAR\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	noEnd := cal.Events()[0]
+	assert.False(t, noEnd.NormalizeAllDayEnd(true))
+	assert.False(t, noEnd.HasProperty(ComponentPropertyDtEnd))
+
+	e := NewEvent("test-timed")
+	e.SetStartAt(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	e.SetEndAt(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	assert.False(t, e.IsAllDayEndInclusiveLikely())
+	assert.False(t, e.NormalizeAllDayEnd(true))
+}
+
+func TestLocalizedDescriptions(t *testing.T) {
+	e := NewEvent("test-localized-description")
+	assert.Equal(t, "", e.DescriptionFor("en"))
+
+	e.SetDescription("Default")
+	e.AddLocalizedDescription("Bonjour", "fr")
+	e.AddLocalizedDescription("G'day", "en-AU")
+	assert.Len(t, e.GetProperties(ComponentPropertyDescription), 3)
+	assert.Equal(t, "Bonjour", e.DescriptionFor("FR"))
+	assert.Equal(t, "G'day", e.DescriptionFor("en-AU"))
+	assert.Equal(t, "G'day", e.DescriptionFor("en"))
+	assert.Equal(t, "Default", e.DescriptionFor("de"))
+}
+
+func TestRoleOf(t *testing.T) {
+	e := NewEvent("test-role-of")
+	e.SetProperty(ComponentPropertyOrganizer, "MAILTO:Boss@Example.com")
+	e.AddAttendee("mailto:me@example.com")
+	e.AddAttendee("mailto:boss@example.com")
+
+	isOrganizer, attendee := e.RoleOf("Me@Example.com")
+	assert.False(t, isOrganizer)
+	if assert.NotNil(t, attendee) {
+		assert.Equal(t, "me@example.com", attendee.Email())
+	}
+
+	isOrganizer, attendee = e.RoleOf("mailto:boss@example.com")
+	assert.True(t, isOrganizer)
+	assert.NotNil(t, attendee)
+
+	isOrganizer, attendee = e.RoleOf("other@example.com")
+	assert.False(t, isOrganizer)
+	assert.Nil(t, attendee)
+}
+
+func TestDataURIAttachment(t *testing.T) {
+	e := NewEvent("test-data-uri")
+	e.AddDataURIAttachment("text/plain", []byte("Hello, World;"))
+	text := strings.ReplaceAll(e.Serialize(defaultSerializationOptions()), "\r\n", "\n")
+	assert.Contains(t, text, "ATTACH;FMTTYPE=text/plain:data:text/plain;base64,SGVsbG8sIFdvcmxkOw==\n")
+
+	parsed, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nVERSION:2.0\n" + text + "END:VCALENDAR\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	mime, data, err := DecodeDataURI(parsed.Events()[0].GetProperty(ComponentPropertyAttach).Value)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain", mime)
+	assert.Equal(t, "Hello, World;", string(data))
+}
+
+func TestDecodeDataURI(t *testing.T) {
+	tests := []struct {
+		value   string
+		mime    string
+		data    string
+		wantErr bool
+	}{
+		{value: "data:text/html,I%20want%20a%20%3Ca%20href%3D%22x%22%3Elink%3C%2Fa%3E.", mime: "text/html", data: `I want a <a href="x">link</a>.`},
+		{value: "data:,A%20brief%20note", mime: "text/plain;charset=US-ASCII", data: "A brief note"},
+		{value: "DATA:;charset=utf-8;base64,w6k=", mime: "text/plain;charset=utf-8", data: "é"},
+		{value: "https://example.com/", wantErr: true},
+		{value: "data:text/plain", wantErr: true},
+		{value: "data:text/plain;base64,!!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			mime, data, err := DecodeDataURI(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.mime, mime)
+			assert.Equal(t, tt.data, string(data))
+		})
+	}
+}
+
+func TestHTMLDescription(t *testing.T) {
+	e := NewEvent("test-html-description")
+	assert.Equal(t, "", e.PlainDescription())
+	_, ok := e.HTMLDescription()
+	assert.False(t, ok)
+
+	altrep := &url.URL{Scheme: "data", Opaque: "text/html;charset=utf-8,See%20the%20%3Ca%20href%3D%22https%3A%2F%2Fexample.com%22%3Eagenda%3C%2Fa%3E"}
+	e.SetDescriptionRich("See the agenda", altrep, "")
+	text := strings.ReplaceAll(e.Serialize(defaultSerializationOptions()), "\r\n", "\n")
+	parsed, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nVERSION:2.0\n" + text + "END:VCALENDAR\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	event := parsed.Events()[0]
+	assert.Equal(t, "See the agenda", event.PlainDescription())
+	html, ok := event.HTMLDescription()
+	assert.True(t, ok)
+	assert.Equal(t, `See the <a href="https://example.com">agenda</a>`, html)
+
+	altrep, _ = url.Parse("https://example.com/agenda.html")
+	e.SetDescriptionRich("See the agenda", altrep, "")
+	_, ok = e.HTMLDescription()
+	assert.False(t, ok)
+}
+
+func TestAddContact(t *testing.T) {
+	e := NewEvent("test-add-contact")
+	vcard, _ := url.Parse("ldap://ldap.example.com:6666/o=ABC%20Industries,c=US???(cn=Jim%20Dolittle)")
+	e.AddContact("Jim Dolittle, ABC Industries, +1-919-555-1234", vcard)
+	e.AddContact("Front desk", nil)
+	text := strings.ReplaceAll(e.Serialize(defaultSerializationOptions()), "\r\n", "\n")
+	parsed, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nVERSION:2.0\n" + text + "END:VCALENDAR\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	contacts := parsed.Events()[0].Contacts()
+	if !assert.Len(t, contacts, 2) {
+		return
+	}
+	assert.Equal(t, "Jim Dolittle, ABC Industries, +1-919-555-1234", contacts[0].Text())
+	altrep, err := contacts[0].AltRep()
+	assert.NoError(t, err)
+	assert.Equal(t, vcard.String(), altrep.String())
+	assert.Equal(t, "Front desk", contacts[1].Text())
+	_, err = contacts[1].AltRep()
+	assert.Error(t, err)
+}
+
+func TestSetHTMLDescription(t *testing.T) {
+	e := NewEvent("test-set-html-description")
+	e.SetHTMLDescription("<p>Agenda</p><ul><li>Fish &amp; chips</li><li>Tea</li></ul>")
+	text := strings.ReplaceAll(e.Serialize(defaultSerializationOptions()), "\r\n", "\n")
+	assert.Contains(t, text, "X-ALT-DESC;FMTTYPE=text/html:<p>Agenda</p>")
+	parsed, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nVERSION:2.0\n" + text + "END:VCALENDAR\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	event := parsed.Events()[0]
+	assert.Equal(t, "Agenda\nFish & chips\nTea", event.PlainDescription())
+	html, ok := event.HTMLDescription()
+	assert.True(t, ok)
+	assert.Equal(t, "<p>Agenda</p><ul><li>Fish &amp; chips</li><li>Tea</li></ul>", html)
+}
+
+func TestTouch(t *testing.T) {
+	created := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	modified := time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)
+	e := NewEvent("test-touch")
+	e.Touch(created)
+	assert.Equal(t, "20240101T090000Z", e.GetProperty(ComponentPropertyCreated).Value)
+	assert.Equal(t, "20240101T090000Z", e.GetProperty(ComponentPropertyDtstamp).Value)
+	assert.Equal(t, "20240101T090000Z", e.GetProperty(ComponentPropertyLastModified).Value)
+
+	e.Touch(modified)
+	assert.Equal(t, "20240101T090000Z", e.GetProperty(ComponentPropertyCreated).Value)
+	assert.Equal(t, "20240201T090000Z", e.GetProperty(ComponentPropertyDtstamp).Value)
+	assert.Equal(t, "20240201T090000Z", e.GetProperty(ComponentPropertyLastModified).Value)
+	assert.Len(t, e.Properties, 4)
+}
+
+func TestContacts(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:test-contacts
+CONTACT;ALTREP="ldap://example.com:6666/o=ABC%20Industries,c=US???(cn=Jim%20Dolittle)":Jim Dolittle\, ABC Industries\, +1-919-555-1234
+CONTACT:Jane Doe
+END:VEVENT
+END:VCALENDAR
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	contacts := cal.Events()[0].Contacts()
+	if !assert.Len(t, contacts, 2) {
+		return
+	}
+	assert.Equal(t, "Jim Dolittle, ABC Industries, +1-919-555-1234", contacts[0].Text())
+	altrep, err := contacts[0].AltRep()
+	assert.NoError(t, err)
+	assert.Equal(t, "ldap", altrep.Scheme)
+	assert.Equal(t, "Jane Doe", contacts[1].Text())
+	_, err = contacts[1].AltRep()
+	assert.Error(t, err)
+}
+
+func TestVEventToICS(t *testing.T) {
+	event := NewEvent("to-ics")
+	event.SetSummary("Planning, round 2")
+	event.SetDtStampTime(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	s, err := event.ToICS()
+	assert.NoError(t, err)
+	assert.Equal(t, "BEGIN:VEVENT\nUID:to-ics\nSUMMARY:Planning\\, round 2\nDTSTAMP:20240102T030405Z\nEND:VEVENT\n", strings.ReplaceAll(s, "\r\n", "\n"))
+
+	s, err = event.ToICS(WithNewLineWindows)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(s, "BEGIN:VEVENT\r\nUID:to-ics\r\n"))
+
+	_, err = event.ToICS(42)
+	assert.Error(t, err)
+}
+
+func TestLookupProperty(t *testing.T) {
+	event := NewEvent("lookup")
+	event.SetSummary("Standup")
+	event.SetProperty(ComponentPropertyLocation, "")
+
+	p, ok := event.LookupProperty(ComponentPropertySummary)
+	assert.True(t, ok)
+	assert.Equal(t, "Standup", p.Value)
+	p, ok = event.LookupProperty(ComponentPropertyDescription)
+	assert.False(t, ok)
+	assert.Nil(t, p)
+
+	v, ok := event.LookupValue(ComponentPropertyLocation)
+	assert.True(t, ok)
+	assert.Equal(t, "", v)
+	v, ok = event.LookupValue(ComponentPropertyDescription)
+	assert.False(t, ok)
+	assert.Equal(t, "", v)
+}
+
+func TestRemoveParameterEverywhere(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:params
+SUMMARY;LANGUAGE=en;X-APPLE-SOURCE=ical:Standup
+LOCATION;X-APPLE-TRAVEL-ADVISORY=on;x-apple-radius=70:Office
+ATTENDEE;CN=Jane;X-NUM-GUESTS=0:mailto:jane@example.com
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER;X-APPLE-DEFAULT=TRUE:-PT15M
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	event := cal.Events()[0]
+
+	assert.Equal(t, 4, event.RemoveParameterEverywhere("X-APPLE-*"))
+	assert.Equal(t, 1, event.RemoveParameterEverywhere("x-num-guests"))
+	assert.Equal(t, 0, event.RemoveParameterEverywhere("X-APPLE-*"))
+
+	s, err := event.ToICS()
+	assert.NoError(t, err)
+	assert.Equal(t, `BEGIN:VEVENT
+UID:params
+SUMMARY;LANGUAGE=en:Standup
+LOCATION:Office
+ATTENDEE;CN=Jane:mailto:jane@example.com
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT15M
+END:VALARM
+END:VEVENT
+`, strings.ReplaceAll(s, "\r\n", "\n"))
+}
+
+func TestVEventAsCalendar(t *testing.T) {
+	source, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+VERSION:2.0
+PRODID:-//Source//EN
+BEGIN:VTIMEZONE
+TZID:Custom/Office
+BEGIN:STANDARD
+DTSTART:19700101T000000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0100
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VTIMEZONE
+TZID:Unused/Zone
+END:VTIMEZONE
+BEGIN:VEVENT
+UID:shared
+DTSTAMP:20240101T090000Z
+DTSTART;TZID=Custom/Office:20240105T090000
+DTEND;TZID=Europe/Berlin:20240105T100000
+END:VEVENT
+END:VCALENDAR
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	event := source.Events()[0]
+	cal := event.AsCalendar(source)
+
+	assert.Equal(t, "2.0", cal.getProperty(PropertyVersion).Value)
+	assert.NotNil(t, cal.getProperty(PropertyProductId))
+	var tzids []string
+	for _, tz := range cal.Timezones() {
+		tzids = append(tzids, tz.GetProperty(ComponentPropertyTzid).Value)
+	}
+	assert.ElementsMatch(t, []string{"Custom/Office", "Europe/Berlin"}, tzids)
+	if !assert.Len(t, cal.Events(), 1) {
+		return
+	}
+	assert.Equal(t, "shared", cal.Events()[0].Id())
+	assert.NotSame(t, event, cal.Events()[0])
+	assert.NoError(t, cal.Validate())
+}
+
+func TestRecurrencePropertyValues(t *testing.T) {
+	e := NewEvent("test-recurrence-values")
+	assert.Empty(t, e.Rrules())
+	e.AddRrule("FREQ=WEEKLY;BYDAY=MO")
+	e.AddRrule("FREQ=MONTHLY;BYMONTHDAY=1")
+	e.AddExrule("FREQ=YEARLY;BYMONTH=1;BYMONTHDAY=1")
+	e.AddRdate("20240105T090000Z,20240106T090000Z")
+	e.AddExdate("20240108T090000Z")
+	e.AddExdate("20240115T090000Z")
+
+	assert.Equal(t, []string{"FREQ=WEEKLY;BYDAY=MO", "FREQ=MONTHLY;BYMONTHDAY=1"}, e.Rrules())
+	assert.Equal(t, []string{"FREQ=YEARLY;BYMONTH=1;BYMONTHDAY=1"}, e.Exrules())
+	assert.Equal(t, []string{"20240105T090000Z,20240106T090000Z"}, e.Rdates())
+	assert.Equal(t, []string{"20240108T090000Z", "20240115T090000Z"}, e.Exdates())
+}
+
+func TestTimeParseError(t *testing.T) {
+	e := NewEvent("test-time-parse-error")
+	e.SetProperty(ComponentPropertyDtStart, "2024-03-01")
+	_, err := e.GetStartAt()
+	var timeErr *TimeParseError
+	if assert.ErrorAs(t, err, &timeErr) {
+		assert.Equal(t, ComponentPropertyDtStart, timeErr.Property)
+		assert.Equal(t, "2024-03-01", timeErr.Value)
+		assert.Equal(t, "time value not matched", timeErr.Reason)
+	}
+	assert.EqualError(t, err, "DTSTART: time value not matched, got '2024-03-01'")
+
+	e.SetProperty(ComponentPropertyDtEnd, "20241301T090000Z")
+	_, err = e.GetEndAt()
+	assert.ErrorAs(t, err, &timeErr)
+	assert.Equal(t, ComponentPropertyDtEnd, timeErr.Property)
+
+	e.SetProperty(ComponentPropertyDtEnd, "20240301T090000", WithTZID("Not/AZone"))
+	_, err = e.GetEndAt()
+	assert.ErrorAs(t, err, &timeErr)
+	assert.NotNil(t, errors.Unwrap(err))
+}
+
+func TestSetBusyFree(t *testing.T) {
+	e := NewEvent("test-busy-free")
+	assert.Equal(t, TransparencyOpaque, e.GetTimeTransparency())
+
+	e.SetFree()
+	assert.Equal(t, TransparencyTransparent, e.GetTimeTransparency())
+	assert.Equal(t, "TRANSPARENT", e.GetProperty(ComponentPropertyTransp).Value)
+
+	e.SetBusy()
+	assert.Equal(t, TransparencyOpaque, e.GetTimeTransparency())
+	assert.Len(t, e.GetProperties(ComponentPropertyTransp), 1)
+
+	e.SetProperty(ComponentPropertyTransp, "transparent")
+	assert.Equal(t, TransparencyTransparent, e.GetTimeTransparency())
+}
+
+func TestVTodoAllDayGetters(t *testing.T) {
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+	todo := NewTodo("test-all-day-getters")
+	todo.SetAllDayDueAt(day)
+	todo.SetAllDayCompletedAt(day.AddDate(0, 0, -1))
+	text := strings.ReplaceAll(todo.Serialize(defaultSerializationOptions()), "\r\n", "\n")
+	assert.Contains(t, text, "DUE;VALUE=DATE:20240301\n")
+	assert.Contains(t, text, "COMPLETED;VALUE=DATE:20240229\n")
+	parsed, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nVERSION:2.0\n" + text + "END:VCALENDAR\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	got := parsed.Todos()[0]
+
+	due, err := got.GetAllDayDueAt()
+	assert.NoError(t, err)
+	assert.Equal(t, day, due)
+	completed, err := got.GetAllDayCompletedAt()
+	assert.NoError(t, err)
+	assert.Equal(t, day.AddDate(0, 0, -1), completed)
+
+	todo.SetCompletedAt(time.Date(2024, 2, 29, 17, 30, 0, 0, time.UTC))
+	completed, err = todo.GetCompletedAt()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 2, 29, 17, 30, 0, 0, time.UTC), completed)
+}
+
+func TestVTodoStartReset(t *testing.T) {
+	todo := NewTodo("test-todo-transitions")
+	todo.SetCompletedAt(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	todo.SetPercentComplete(100)
+	todo.SetStatus(ObjectStatusCompleted)
+
+	todo.Start(time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, string(ObjectStatusInProcess), todo.GetProperty(ComponentPropertyStatus).Value)
+	assert.Equal(t, "20240102T090000Z", todo.GetProperty(ComponentPropertyDtStart).Value)
+	assert.False(t, todo.HasProperty(ComponentPropertyCompleted))
+	assert.True(t, todo.HasProperty(ComponentPropertyPercentComplete))
+
+	todo.SetCompletedAt(time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC))
+	todo.Reset()
+	assert.Equal(t, string(ObjectStatusNeedsAction), todo.GetProperty(ComponentPropertyStatus).Value)
+	assert.False(t, todo.HasProperty(ComponentPropertyCompleted))
+	assert.False(t, todo.HasProperty(ComponentPropertyPercentComplete))
+	assert.True(t, todo.HasProperty(ComponentPropertyDtStart))
+}
+
+func TestDeduplicate(t *testing.T) {
+	parse := func() *VEvent {
+		cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:dedupe
+SUMMARY:First
+ATTENDEE:mailto:a@example.com
+SUMMARY:Second
+ATTENDEE:mailto:b@example.com
+LOCATION:Here
+SUMMARY:Third
+END:VEVENT
+END:VCALENDAR
+`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cal.Events()[0]
+	}
+
+	event := parse()
+	removed := event.Deduplicate()
+	assert.Len(t, removed, 2)
+	assert.Equal(t, "Second", removed[0].Value)
+	assert.Equal(t, []string{"First"}, event.PropertyMap()[ComponentPropertySummary])
+	assert.Len(t, event.Attendees(), 2)
+	assert.Empty(t, event.Deduplicate())
+
+	event = parse()
+	removed = event.DeduplicateKeepLast()
+	assert.Len(t, removed, 2)
+	assert.Equal(t, "First", removed[0].Value)
+	assert.Equal(t, []string{"Third"}, event.PropertyMap()[ComponentPropertySummary])
+	assert.Equal(t, "Here", event.GetProperty(ComponentPropertyLocation).Value)
+
+	todo := NewTodo("dedupe-todo")
+	todo.SetPercentComplete(10)
+	todo.AddProperty(ComponentPropertyPercentComplete, "50")
+	todo.AddComment("One")
+	todo.AddComment("Two")
+	removed = todo.DeduplicateKeepLast()
+	assert.Len(t, removed, 1)
+	assert.Equal(t, "50", todo.GetProperty(ComponentPropertyPercentComplete).Value)
+	assert.Len(t, todo.GetProperties(ComponentPropertyComment), 2)
+
+	journal := NewJournal("dedupe-journal")
+	journal.AddProperty(ComponentPropertyDescription, "Morning")
+	journal.AddProperty(ComponentPropertyDescription, "Evening")
+	assert.Empty(t, journal.Deduplicate())
+	assert.True(t, ComponentPropertyDescription.Singular(NewEvent("dedupe-event")))
+
+	event = NewEvent("dedupe-description")
+	event.AddProperty(ComponentPropertyDescription, "Old")
+	event.AddProperty(ComponentPropertyDescription, "New")
+	removed = event.DeduplicateKeepLast()
+	if assert.Len(t, removed, 1) {
+		assert.Equal(t, "Old", removed[0].Value)
+	}
+	assert.Equal(t, []string{"New"}, event.PropertyMap()[ComponentPropertyDescription])
+
+	alarm := NewEvent("dedupe-alarm").AddAlarm()
+	alarm.AddProperty(ComponentPropertyDescription, "Ring")
+	alarm.AddProperty(ComponentPropertyDescription, "Again")
+	assert.Len(t, alarm.Deduplicate(), 1)
+}
+
+func TestVBusyFreeBusy(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VFREEBUSY
+UID:fb
+FREEBUSY:19980314T233000Z/19980315T003000Z
+FREEBUSY;FBTYPE=FREE:19980308T160000Z/PT3H,19980308T200000Z/PT1H30M
+FREEBUSY;FBTYPE=BUSY-TENTATIVE:19980309T160000Z/19980309T170000Z
+END:VFREEBUSY
+BEGIN:VFREEBUSY
+UID:broken
+FREEBUSY:19980314T233000Z
+END:VFREEBUSY
+END:VCALENDAR
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	busys := cal.Busys()
+	periods, err := busys[0].FreeBusy()
+	if !assert.NoError(t, err) {
+		return
+	}
+	utc := func(s string) time.Time {
+		v, err := time.Parse(icalTimestampFormatUtc, s)
+		assert.NoError(t, err)
+		return v
+	}
+	assert.Equal(t, []Period{
+		{Start: utc("19980314T233000Z"), End: utc("19980315T003000Z"), Type: FreeBusyTimeTypeBusy},
+		{Start: utc("19980308T160000Z"), End: utc("19980308T190000Z"), Type: FreeBusyTimeTypeFree},
+		{Start: utc("19980308T200000Z"), End: utc("19980308T213000Z"), Type: FreeBusyTimeTypeFree},
+		{Start: utc("19980309T160000Z"), End: utc("19980309T170000Z"), Type: FreeBusyTimeTypeBusyTentative},
+	}, periods)
+
+	_, err = busys[1].FreeBusy()
+	assert.Error(t, err)
+}
+
+func TestUseDurationUseDtEnd(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		start    string
+		end      string
+		duration string
+	}{
+		{name: "utc", start: "DTSTART:20240301T090000Z", end: "DTEND:20240301T103000Z", duration: "PT1H30M"},
+		{name: "all day", start: "DTSTART;VALUE=DATE:20240301", end: "DTEND;VALUE=DATE:20240303", duration: "P2D"},
+		{name: "across dst", start: "DTSTART;TZID=Europe/Berlin:20240330T090000", end: "DTEND;TZID=Europe/Berlin:20240331T100000", duration: "P1DT1H"},
+		{name: "zero", start: "DTSTART:20240301T090000Z", end: "DTEND:20240301T090000Z", duration: "PT0S"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\n" + tt.start + "\n" + tt.end + "\nEND:VEVENT\nEND:VCALENDAR\n"))
+			if !assert.NoError(t, err) {
+				return
+			}
+			event := cal.Events()[0]
+			original := event.Serialize(defaultSerializationOptions())
+
+			assert.NoError(t, event.UseDuration())
+			assert.False(t, event.HasProperty(ComponentPropertyDtEnd))
+			assert.Equal(t, tt.duration, event.GetProperty(ComponentPropertyDuration).Value)
+			assert.NoError(t, event.UseDuration())
+
+			assert.NoError(t, event.UseDtEnd())
+			assert.False(t, event.HasProperty(ComponentPropertyDuration))
+			assert.Equal(t, original, event.Serialize(defaultSerializationOptions()))
+		})
+	}
+}
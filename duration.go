@@ -0,0 +1,147 @@
+package ics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration is a RFC5545 DURATION value. Weeks and days are nominal, so they are kept separate from the exact time
+// portion to allow them to be applied across daylight saving transitions correctly.
+type Duration struct {
+	Negative bool
+	Weeks    int
+	Days     int
+	Hours    int
+	Minutes  int
+	Seconds  int
+}
+
+// ParseDuration parses a DURATION value such as "-PT15M" or "P1DT12H".
+// https://www.rfc-editor.org/rfc/rfc5545#section-3.3.6
+func ParseDuration(s string) (Duration, error) {
+	d := Duration{}
+	v := s
+	switch {
+	case strings.HasPrefix(v, "-"):
+		d.Negative = true
+		v = v[1:]
+	case strings.HasPrefix(v, "+"):
+		v = v[1:]
+	}
+	if !strings.HasPrefix(v, "P") || len(v) < 3 {
+		return Duration{}, fmt.Errorf("duration %q: expected P", s)
+	}
+	v = v[1:]
+	inTime := false
+	n := ""
+	for _, r := range v {
+		switch {
+		case r >= '0' && r <= '9':
+			n += string(r)
+			continue
+		case r == 'T' && !inTime && n == "":
+			inTime = true
+			continue
+		}
+		if n == "" {
+			return Duration{}, fmt.Errorf("duration %q: missing number before %q", s, r)
+		}
+		i, err := strconv.Atoi(n)
+		if err != nil {
+			return Duration{}, fmt.Errorf("duration %q: %w", s, err)
+		}
+		n = ""
+		switch {
+		case r == 'W' && !inTime:
+			d.Weeks = i
+		case r == 'D' && !inTime:
+			d.Days = i
+		case r == 'H' && inTime:
+			d.Hours = i
+		case r == 'M' && inTime:
+			d.Minutes = i
+		case r == 'S' && inTime:
+			d.Seconds = i
+		default:
+			return Duration{}, fmt.Errorf("duration %q: unexpected %q", s, r)
+		}
+	}
+	if n != "" {
+		return Duration{}, fmt.Errorf("duration %q: trailing number", s)
+	}
+	return d, nil
+}
+
+// NewDuration returns the DURATION equivalent to d, with whole multiples of 24 hours expressed as days. Fractions of a
+// second are dropped.
+func NewDuration(d time.Duration) Duration {
+	r := Duration{}
+	if d < 0 {
+		r.Negative = true
+		d = -d
+	}
+	r.Days = int(d / (24 * time.Hour))
+	d %= 24 * time.Hour
+	r.Hours = int(d / time.Hour)
+	d %= time.Hour
+	r.Minutes = int(d / time.Minute)
+	d %= time.Minute
+	r.Seconds = int(d / time.Second)
+	return r
+}
+
+// AddTo returns t moved by the duration, with weeks and days applied as calendar days in t's location.
+func (d Duration) AddTo(t time.Time) time.Time {
+	sign := 1
+	if d.Negative {
+		sign = -1
+	}
+	t = t.AddDate(0, 0, sign*(d.Weeks*7+d.Days))
+	return t.Add(time.Duration(sign) * d.exact())
+}
+
+// Duration returns the duration as a time.Duration treating each day as exactly 24 hours.
+func (d Duration) Duration() time.Duration {
+	r := time.Duration(d.Weeks*7+d.Days)*24*time.Hour + d.exact()
+	if d.Negative {
+		return -r
+	}
+	return r
+}
+
+func (d Duration) exact() time.Duration {
+	return time.Duration(d.Hours)*time.Hour + time.Duration(d.Minutes)*time.Minute + time.Duration(d.Seconds)*time.Second
+}
+
+// String formats the duration as a RFC5545 DURATION value.
+func (d Duration) String() string {
+	b := &strings.Builder{}
+	if d.Negative {
+		b.WriteString("-")
+	}
+	b.WriteString("P")
+	if d.Weeks != 0 {
+		fmt.Fprintf(b, "%dW", d.Weeks)
+	}
+	if d.Days != 0 {
+		fmt.Fprintf(b, "%dD", d.Days)
+	}
+	if d.Hours != 0 || d.Minutes != 0 || d.Seconds != 0 {
+		b.WriteString("T")
+		if d.Hours != 0 {
+			fmt.Fprintf(b, "%dH", d.Hours)
+		}
+		if d.Minutes != 0 {
+			fmt.Fprintf(b, "%dM", d.Minutes)
+		}
+		if d.Seconds != 0 {
+			fmt.Fprintf(b, "%dS", d.Seconds)
+		}
+	}
+	if d.Weeks == 0 && d.Days == 0 && d.Hours == 0 && d.Minutes == 0 && d.Seconds == 0 {
+		b.WriteString("T0S")
+	}
+	return b.String()
+}
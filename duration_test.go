@@ -0,0 +1,68 @@
+package ics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected Duration
+		wantErr  bool
+	}{
+		{input: "PT15M", expected: Duration{Minutes: 15}},
+		{input: "-PT15M", expected: Duration{Negative: true, Minutes: 15}},
+		{input: "+P1D", expected: Duration{Days: 1}},
+		{input: "P2W", expected: Duration{Weeks: 2}},
+		{input: "P15DT5H0M20S", expected: Duration{Days: 15, Hours: 5, Seconds: 20}},
+		{input: "PT0S", expected: Duration{}},
+		{input: "P", wantErr: true},
+		{input: "15M", wantErr: true},
+		{input: "PT15", wantErr: true},
+		{input: "P1H", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestDurationString(t *testing.T) {
+	for _, s := range []string{"PT15M", "-PT15M", "P1D", "P2W", "P15DT5H20S", "PT0S"} {
+		d, err := ParseDuration(s)
+		assert.NoError(t, err)
+		assert.Equal(t, s, d.String())
+	}
+}
+
+func TestDurationAddTo(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Copenhagen")
+	if err != nil {
+		t.Skipf("location unavailable: %v", err)
+	}
+	// Spans the 2024-03-31 daylight saving change, a nominal day keeps the wall clock time.
+	start := time.Date(2024, 3, 30, 9, 0, 0, 0, loc)
+	d := Duration{Days: 1, Hours: 1}
+	assert.Equal(t, time.Date(2024, 3, 31, 10, 0, 0, 0, loc), d.AddTo(start))
+	assert.Equal(t, 25*time.Hour, d.Duration())
+}
+
+func TestNewDuration(t *testing.T) {
+	assert.Equal(t, "PT1H30M", NewDuration(90*time.Minute).String())
+	assert.Equal(t, "P1DT1S", NewDuration(24*time.Hour+time.Second+time.Millisecond).String())
+	assert.Equal(t, "-PT15M", NewDuration(-15*time.Minute).String())
+	assert.Equal(t, "PT0S", NewDuration(0).String())
+	for _, d := range []time.Duration{time.Hour, 36 * time.Hour, -90 * time.Second} {
+		assert.Equal(t, d, NewDuration(d).Duration())
+	}
+}
@@ -1,9 +1,67 @@
 package ics
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	// ErrorPropertyNotFound is the error returned if the requested valid
 	// property is not set.
 	ErrorPropertyNotFound = errors.New("property not found")
+
+	// ErrorAttachmentTooLarge is the error returned if an attachment exceeds
+	// the permitted size.
+	ErrorAttachmentTooLarge = errors.New("attachment too large")
+
+	// ErrorUnresolvableTZID is the error returned by validation if a TZID is
+	// neither defined by a VTIMEZONE nor a known location.
+	ErrorUnresolvableTZID = errors.New("unresolvable TZID")
+
+	// ErrorUnboundedRecurrence is the error returned if expanding a
+	// recurrence rule which never ends is requested without an end.
+	ErrorUnboundedRecurrence = errors.New("unbounded recurrence")
+
+	// ErrorUnknownProperty is the error returned when parsing strictly if a
+	// property is neither defined by the RFCs, an X- property nor registered.
+	ErrorUnknownProperty = errors.New("unknown property")
+
+	// ErrorUnknownComponent is the error returned when parsing strictly if a
+	// component is neither defined by the RFCs nor an X- component.
+	ErrorUnknownComponent = errors.New("unknown component")
+
+	// ErrorComponentIndexOutOfRange is the error returned if a component is
+	// requested by a position the calendar does not have.
+	ErrorComponentIndexOutOfRange = errors.New("component index out of range")
+
+	// ErrorMissingRequiredProperty is the error returned by validation if a
+	// component lacks a property required of it.
+	ErrorMissingRequiredProperty = errors.New("missing required property")
+
+	// ErrorInvalidColor is the error returned if a COLOR is not a CSS3 color
+	// name as RFC7986 requires.
+	ErrorInvalidColor = errors.New("invalid color")
+
+	// ErrorInvalidCalAddress is the error returned by validation if an
+	// ATTENDEE or ORGANIZER is not a usable cal-address.
+	ErrorInvalidCalAddress = errors.New("invalid cal-address")
 )
+
+// TimeParseError is the error returned when the value of a date or date-time
+// property can not be read as a time, such as a malformed DTSTART, so the
+// component can be skipped without matching on the error message.
+type TimeParseError struct {
+	Property ComponentProperty
+	Value    string
+	Reason   string
+	// Err is the underlying error, such as from loading the TZID.
+	Err error
+}
+
+func (e *TimeParseError) Error() string {
+	return fmt.Sprintf("%s: %s, got '%s'", e.Property, e.Reason, e.Value)
+}
+
+func (e *TimeParseError) Unwrap() error {
+	return e.Err
+}
@@ -11,6 +11,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"unicode/utf8"
 )
 
@@ -18,6 +20,24 @@ type BaseProperty struct {
 	IANAToken      string
 	ICalParameters map[string][]string
 	Value          string
+	// RawValue is the value exactly as it appeared in the input, it is only set when parsing with
+	// WithPreserveRawValues. It is serialized in place of Value for as long as Value is unmodified.
+	RawValue string
+}
+
+func (bp *BaseProperty) clone() BaseProperty {
+	r := BaseProperty{
+		IANAToken: bp.IANAToken,
+		Value:     bp.Value,
+		RawValue:  bp.RawValue,
+	}
+	if bp.ICalParameters != nil {
+		r.ICalParameters = make(map[string][]string, len(bp.ICalParameters))
+		for k, v := range bp.ICalParameters {
+			r.ICalParameters[k] = append([]string(nil), v...)
+		}
+	}
+	return r
 }
 
 type PropertyParameter interface {
@@ -55,6 +75,13 @@ func WithAlternativeRepresentation(uri *url.URL) PropertyParameter {
 	}
 }
 
+func WithLanguage(lang string) PropertyParameter {
+	return &KeyValues{
+		Key:   string(ParameterLanguage),
+		Value: []string{lang},
+	}
+}
+
 func WithEncoding(encType string) PropertyParameter {
 	return &KeyValues{
 		Key:   string(ParameterEncoding),
@@ -76,6 +103,48 @@ func WithValue(kind string) PropertyParameter {
 	}
 }
 
+// WithRange sets the RANGE parameter, used on RECURRENCE-ID. See RecurrenceRangeThisAndFuture
+func WithRange(r string) PropertyParameter {
+	return &KeyValues{
+		Key:   string(ParameterRange),
+		Value: []string{r},
+	}
+}
+
+// WithMember sets the MEMBER parameter to the cal-addresses of the groups the calendar user belongs to
+func WithMember(calAddresses ...string) PropertyParameter {
+	return &KeyValues{
+		Key:   string(ParameterMember),
+		Value: calAddresses,
+	}
+}
+
+// WithDelegatedTo sets the DELEGATED-TO parameter to the cal-addresses delegated to
+func WithDelegatedTo(calAddresses ...string) PropertyParameter {
+	return &KeyValues{
+		Key:   string(ParameterDelegatedTo),
+		Value: calAddresses,
+	}
+}
+
+// WithDelegatedFrom sets the DELEGATED-FROM parameter to the cal-addresses delegated from
+func WithDelegatedFrom(calAddresses ...string) PropertyParameter {
+	return &KeyValues{
+		Key:   string(ParameterDelegatedFrom),
+		Value: calAddresses,
+	}
+}
+
+// WithScheduleStatus sets the RFC6638 SCHEDULE-STATUS parameter to the iTIP request status codes, such as "2.0", of the
+// delivery of a scheduling message to an ATTENDEE or ORGANIZER. Several codes are written each quoted and comma
+// separated, as in SCHEDULE-STATUS="3.7","5.1", following the grammar of RFC6638 section 9.2.
+func WithScheduleStatus(codes ...string) PropertyParameter {
+	return &KeyValues{
+		Key:   string(ParameterScheduleStatus),
+		Value: codes,
+	}
+}
+
 func WithRSVP(b bool) PropertyParameter {
 	return &KeyValues{
 		Key:   string(ParameterRsvp),
@@ -118,6 +187,60 @@ func (bp *BaseProperty) parameterValue(param Parameter) (string, error) {
 	return v[0], nil
 }
 
+var (
+	propertyValueTypesMu sync.RWMutex
+	propertyValueTypes   = map[string]ValueDataType{}
+)
+
+// RegisterPropertyValueType declares the default value type of properties named token, such as a vendor's X- property
+// holding a URI, which would otherwise be treated as TEXT and escaped on serialization. A VALUE parameter on a property
+// still takes precedence. Registering a property defined by the RFC overrides its default.
+func RegisterPropertyValueType(token string, t ValueDataType) {
+	propertyValueTypesMu.Lock()
+	defer propertyValueTypesMu.Unlock()
+	propertyValueTypes[strings.ToUpper(token)] = t
+}
+
+func registeredPropertyValueType(token string) (ValueDataType, bool) {
+	propertyValueTypesMu.RLock()
+	defer propertyValueTypesMu.RUnlock()
+	t, ok := propertyValueTypes[strings.ToUpper(token)]
+	return t, ok
+}
+
+var (
+	noFoldPropertiesMu sync.RWMutex
+	noFoldProperties   = map[string]bool{}
+)
+
+// RegisterNoFoldProperty declares that properties named token, such as GEO or UID, or a vendor's X- property, are always
+// written on a single line however long, for clients which mishandle them folded. Such lines exceed the 75 octets
+// RFC5545 recommends, so only register properties known to cause trouble.
+func RegisterNoFoldProperty(token string) {
+	noFoldPropertiesMu.Lock()
+	defer noFoldPropertiesMu.Unlock()
+	noFoldProperties[strings.ToUpper(token)] = true
+}
+
+func isNoFoldProperty(token string) bool {
+	noFoldPropertiesMu.RLock()
+	defer noFoldPropertiesMu.RUnlock()
+	return noFoldProperties[strings.ToUpper(token)]
+}
+
+// SortedParameters returns the property's parameters ordered by name, the order they are serialized in. The value
+// slices are those of ICalParameters rather than copies.
+func (bp *BaseProperty) SortedParameters() []KeyValues {
+	r := make([]KeyValues, 0, len(bp.ICalParameters))
+	for k, v := range bp.ICalParameters {
+		r = append(r, KeyValues{Key: k, Value: v})
+	}
+	sort.Slice(r, func(i, j int) bool {
+		return r[i].Key < r[j].Key
+	})
+	return r
+}
+
 func (bp *BaseProperty) GetValueType() ValueDataType {
 	for k, v := range bp.ICalParameters {
 		if Parameter(k) == ParameterValue && len(v) == 1 {
@@ -125,6 +248,10 @@ func (bp *BaseProperty) GetValueType() ValueDataType {
 		}
 	}
 
+	if t, ok := registeredPropertyValueType(bp.IANAToken); ok {
+		return t
+	}
+
 	// defaults from spec if unspecified
 	switch Property(bp.IANAToken) {
 	default:
@@ -165,17 +292,49 @@ func (bp *BaseProperty) GetValueType() ValueDataType {
 	}
 }
 
+// utcCopy returns a copy of the property with any TZID qualified DATE-TIME values converted to UTC, or the property
+// itself if no conversion was possible. TZIDs are looked up in locations before time.LoadLocation.
+func (bp *BaseProperty) utcCopy(locations map[string]*time.Location) *BaseProperty {
+	tzid, err := bp.parameterValue(ParameterTzid)
+	if err != nil || bp.GetValueType() != ValueDataTypeDateTime {
+		return bp
+	}
+	loc, ok := locations[tzid]
+	if !ok {
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return bp
+		}
+	}
+	values := strings.Split(bp.Value, ",")
+	for i, v := range values {
+		t, err := time.ParseInLocation(icalTimestampFormatLocal, v, loc)
+		if err != nil {
+			return bp
+		}
+		values[i] = t.UTC().Format(icalTimestampFormatUtc)
+	}
+	r := &BaseProperty{
+		IANAToken:      bp.IANAToken,
+		ICalParameters: map[string][]string{},
+		Value:          strings.Join(values, ","),
+	}
+	for k, v := range bp.ICalParameters {
+		if k != string(ParameterTzid) {
+			r.ICalParameters[k] = v
+		}
+	}
+	return r
+}
+
 func (bp *BaseProperty) serialize(w io.Writer, serialConfig *SerializationConfiguration) error {
+	if serialConfig.ForceUTC {
+		bp = bp.utcCopy(serialConfig.locations)
+	}
 	b := bytes.NewBufferString("")
 	_, _ = fmt.Fprint(b, bp.IANAToken)
 
-	var keys []string
-	for k := range bp.ICalParameters {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-	for _, k := range keys {
-		vs := bp.ICalParameters[k]
+	for _, kv := range bp.SortedParameters() {
+		k, vs := kv.Key, kv.Value
 		_, _ = fmt.Fprint(b, ";")
 		_, _ = fmt.Fprint(b, k)
 		_, _ = fmt.Fprint(b, "=")
@@ -194,12 +353,14 @@ func (bp *BaseProperty) serialize(w io.Writer, serialConfig *SerializationConfig
 	}
 	_, _ = fmt.Fprint(b, ":")
 	propertyValue := bp.Value
-	if bp.GetValueType() == ValueDataTypeText {
+	if bp.RawValue != "" && bp.decodeValue(bp.RawValue) == bp.Value {
+		propertyValue = bp.RawValue
+	} else if bp.GetValueType() == ValueDataTypeText {
 		propertyValue = ToText(propertyValue)
 	}
 	_, _ = fmt.Fprint(b, propertyValue)
 	r := b.String()
-	if len(r) > serialConfig.MaxLength {
+	if len(r) > serialConfig.MaxLength && !isNoFoldProperty(bp.IANAToken) {
 		l := trimUT8StringUpTo(serialConfig.MaxLength, r)
 		_, err := fmt.Fprint(w, l, serialConfig.NewLine)
 		if err != nil {
@@ -263,6 +424,50 @@ type IANAProperty struct {
 	BaseProperty
 }
 
+// AltRep returns the ALTREP parameter as a URL. Surrounding quotes, which the parser removes but may be present on
+// programmatically set values, are stripped first.
+func (p *IANAProperty) AltRep() (*url.URL, error) {
+	v, err := p.parameterValue(ParameterAltrep)
+	if err != nil {
+		return nil, err
+	}
+	if len(v) >= 2 && strings.HasPrefix(v, `"`) && strings.HasSuffix(v, `"`) {
+		v = v[1 : len(v)-1]
+	}
+	u, err := url.Parse(v)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", ParameterAltrep, err)
+	}
+	return u, nil
+}
+
+// DisplayValue returns the value formatted for showing to a person. Date and time values are rendered in their time
+// zone, durations as Go durations and GEO as a coordinate pair. Anything else, including TEXT which is held unescaped,
+// is returned as is.
+func (p *IANAProperty) DisplayValue() string {
+	switch p.GetValueType() {
+	case ValueDataTypeDateTime:
+		if t, err := p.parseTime(false); err == nil {
+			return t.Format("2006-01-02 15:04:05 MST")
+		}
+	case ValueDataTypeDate:
+		if t, err := p.parseTime(true); err == nil {
+			return t.Format("2006-01-02")
+		}
+	case ValueDataTypeDuration:
+		if d, err := ParseDuration(p.Value); err == nil {
+			return d.Duration().String()
+		}
+	case ValueDataTypeFloat:
+		if Property(p.IANAToken) == PropertyGeo {
+			if lat, lng, ok := strings.Cut(p.Value, ";"); ok {
+				return lat + ", " + lng
+			}
+		}
+	}
+	return p.Value
+}
+
 var (
 	propertyIanaTokenReg *regexp.Regexp
 	propertyParamNameReg *regexp.Regexp
@@ -285,6 +490,11 @@ func init() {
 type ContentLine string
 
 func ParseProperty(contentLine ContentLine) (*BaseProperty, error) {
+	return parseProperty(contentLine, false)
+}
+
+// parseProperty parses a content line, when keepRaw is set the undecoded value is retained for serialization.
+func parseProperty(contentLine ContentLine, keepRaw bool) (*BaseProperty, error) {
 	r := &BaseProperty{
 		ICalParameters: map[string][]string{},
 	}
@@ -301,7 +511,7 @@ func ParseProperty(contentLine ContentLine) (*BaseProperty, error) {
 		}
 		switch rune(contentLine[p]) {
 		case ':':
-			return parsePropertyValue(r, string(contentLine), p+1), nil
+			return parsePropertyValue(r, string(contentLine), p+1, keepRaw), nil
 		case ';':
 			var np int
 			var err error
@@ -426,18 +636,26 @@ func parsePropertyParamValue(s string, p int) (string, int, error) {
 	return string(r), p, nil
 }
 
-func parsePropertyValue(r *BaseProperty, contentLine string, p int) *BaseProperty {
+func parsePropertyValue(r *BaseProperty, contentLine string, p int, keepRaw bool) *BaseProperty {
 	tokenPos := propertyValueTextReg.FindIndex([]byte(contentLine[p:]))
 	if tokenPos == nil {
 		return nil
 	}
-	r.Value = contentLine[p : p+tokenPos[1]]
-	if r.GetValueType() == ValueDataTypeText {
-		r.Value = FromText(r.Value)
+	r.Value = r.decodeValue(contentLine[p : p+tokenPos[1]])
+	if keepRaw {
+		r.RawValue = contentLine[p : p+tokenPos[1]]
 	}
 	return r
 }
 
+// decodeValue unescapes a value as it appears on the wire according to the property's value type.
+func (bp *BaseProperty) decodeValue(s string) string {
+	if bp.GetValueType() == ValueDataTypeText {
+		return FromText(s)
+	}
+	return s
+}
+
 var textEscaper = strings.NewReplacer(
 	`\`, `\\`,
 	"\n", `\n`,
@@ -1,6 +1,7 @@
 package ics
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -86,6 +87,24 @@ func TestPropertyParse(t *testing.T) {
 				expected.Check(t, output)
 			}
 		}},
+		{Name: "Quoted parameter value containing a comma", Input: "ATTENDEE;CN=\"Doe, John\";ROLE=REQ-PARTICIPANT:mailto:john@example.com", Expected: func(t *testing.T, output *BaseProperty, err error) {
+			assert.NoError(t, err)
+			assert.Equal(t, "mailto:john@example.com", output.Value)
+			for _, expected := range []*PropertyValueCheck{
+				NewPropertyValueCheck("CN", "Doe, John"),
+				NewPropertyValueCheck("ROLE", "REQ-PARTICIPANT"),
+			} {
+				expected.Check(t, output)
+			}
+		}},
+		{Name: "Quoted parameter values containing commas in a list", Input: "ATTENDEE;CN=\"Doe, John\",\"Roe, Jane\":mailto:john@example.com", Expected: func(t *testing.T, output *BaseProperty, err error) {
+			assert.NoError(t, err)
+			for _, expected := range []*PropertyValueCheck{
+				NewPropertyValueCheck("CN", "Doe, John", "Roe, Jane"),
+			} {
+				expected.Check(t, output)
+			}
+		}},
 	}
 	for _, test := range tests {
 		t.Run(test.Name, func(t *testing.T) {
@@ -253,3 +272,147 @@ func TestFixValueStrings(t *testing.T) {
 		})
 	}
 }
+
+func TestDisplayValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "text", input: `SUMMARY:Lunch\, then coffee\; maybe`, expected: "Lunch, then coffee; maybe"},
+		{name: "utc date time", input: "DTSTART:20240301T090000Z", expected: "2024-03-01 09:00:00 UTC"},
+		{name: "zoned date time", input: "DTSTART;TZID=Europe/Copenhagen:20240301T090000", expected: "2024-03-01 09:00:00 CET"},
+		{name: "date", input: "DTSTART;VALUE=DATE:20240301", expected: "2024-03-01"},
+		{name: "duration", input: "DURATION:PT1H30M", expected: "1h30m0s"},
+		{name: "geo", input: "GEO:37.386013;-122.082932", expected: "37.386013, -122.082932"},
+		{name: "uri", input: "URL:https://example.com/a,b", expected: "https://example.com/a,b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bp, err := ParseProperty(ContentLine(tt.input))
+			assert.NoError(t, err)
+			p := &IANAProperty{*bp}
+			assert.Equal(t, tt.expected, p.DisplayValue())
+		})
+	}
+}
+
+func TestAltRep(t *testing.T) {
+	bp, err := ParseProperty(`DESCRIPTION;ALTREP="cid:part1.0001@example.org":The event`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	p := &IANAProperty{*bp}
+	u, err := p.AltRep()
+	assert.NoError(t, err)
+	assert.Equal(t, "cid:part1.0001@example.org", u.String())
+
+	p.ICalParameters[string(ParameterAltrep)] = []string{`"https://example.com/a"`}
+	u, err = p.AltRep()
+	assert.NoError(t, err)
+	assert.Equal(t, "https", u.Scheme)
+
+	delete(p.ICalParameters, string(ParameterAltrep))
+	_, err = p.AltRep()
+	assert.Error(t, err)
+}
+
+func TestMultiValuedParameterSerialization(t *testing.T) {
+	e := NewEvent("test-multi-valued")
+	e.AddAttendee("john@example.com",
+		WithMember("mailto:dev@example.com", "mailto:ops@example.com"),
+		WithDelegatedTo("mailto:jane@example.com"),
+	)
+	text := strings.ReplaceAll(e.Serialize(defaultSerializationOptions()), "\r\n", "\n")
+	assert.Equal(t, `BEGIN:VEVENT
+UID:test-multi-valued
+ATTENDEE;DELEGATED-TO="mailto:jane@example.com";MEMBER="mailto:dev@example.
+ com","mailto:ops@example.com":mailto:john@example.com
+END:VEVENT
+`, text)
+
+	parsed, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\n" + text + "END:VCALENDAR\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	attendee := parsed.Events()[0].Attendees()[0]
+	assert.Equal(t, []string{"mailto:dev@example.com", "mailto:ops@example.com"}, attendee.ICalParameters[string(ParameterMember)])
+	assert.Equal(t, []string{"mailto:jane@example.com"}, attendee.ICalParameters[string(ParameterDelegatedTo)])
+	assert.Equal(t, "john@example.com", attendee.Email())
+}
+
+func TestScheduleStatus(t *testing.T) {
+	e := NewEvent("test-schedule-status")
+	e.AddAttendee("mailto:a@example.com", WithScheduleStatus("2.0"))
+	e.AddAttendee("mailto:b@example.com", WithScheduleStatus("3.7", "5.1"))
+	e.AddAttendee("mailto:c@example.com")
+	text := strings.ReplaceAll(e.Serialize(defaultSerializationOptions()), "\r\n", "\n")
+	assert.Contains(t, text, "ATTENDEE;SCHEDULE-STATUS=\"2.0\":mailto:a@example.com\n")
+	assert.Contains(t, text, "ATTENDEE;SCHEDULE-STATUS=\"3.7\",\"5.1\":mailto:b@example.com\n")
+
+	cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nVERSION:2.0\n" + text + "END:VCALENDAR\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	attendees := cal.Events()[0].Attendees()
+	assert.Equal(t, "2.0", attendees[0].ScheduleStatus())
+	assert.Equal(t, "3.7,5.1", attendees[1].ScheduleStatus())
+	assert.Equal(t, []string{"3.7", "5.1"}, attendees[1].ICalParameters[string(ParameterScheduleStatus)])
+	assert.Equal(t, "", attendees[2].ScheduleStatus())
+	assert.Equal(t, text, strings.ReplaceAll(cal.Events()[0].Serialize(defaultSerializationOptions()), "\r\n", "\n"))
+
+	// Some clients quote the whole list instead
+	cal, err = ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nATTENDEE;SCHEDULE-STATUS=\"3.7,5.1\":mailto:b@example.com\nEND:VEVENT\nEND:VCALENDAR\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "3.7,5.1", cal.Events()[0].Attendees()[0].ScheduleStatus())
+}
+
+func TestRegisterPropertyValueType(t *testing.T) {
+	e := NewEvent("test-registered-value-type")
+	e.SetProperty(ComponentProperty("X-EXAMPLE-LINK"), "https://example.com/a,b;c")
+	assert.Contains(t, e.Serialize(defaultSerializationOptions()), `X-EXAMPLE-LINK:https://example.com/a\,b\;c`)
+
+	RegisterPropertyValueType("x-example-link", ValueDataTypeUri)
+	defer func() {
+		propertyValueTypesMu.Lock()
+		delete(propertyValueTypes, "X-EXAMPLE-LINK")
+		propertyValueTypesMu.Unlock()
+	}()
+	assert.Equal(t, ValueDataTypeUri, e.GetProperty(ComponentProperty("X-EXAMPLE-LINK")).GetValueType())
+	assert.Contains(t, e.Serialize(defaultSerializationOptions()), "X-EXAMPLE-LINK:https://example.com/a,b;c")
+
+	e.SetProperty(ComponentProperty("X-EXAMPLE-LINK"), "a,b", WithValue(string(ValueDataTypeText)))
+	assert.Equal(t, ValueDataTypeText, e.GetProperty(ComponentProperty("X-EXAMPLE-LINK")).GetValueType())
+}
+
+func TestRegisterNoFoldProperty(t *testing.T) {
+	e := NewEvent("test-no-fold-" + strings.Repeat("0123456789", 8))
+	folded := e.Serialize(defaultSerializationOptions())
+	assert.NotContains(t, folded, e.Id())
+
+	RegisterNoFoldProperty("uid")
+	defer func() {
+		noFoldPropertiesMu.Lock()
+		delete(noFoldProperties, "UID")
+		noFoldPropertiesMu.Unlock()
+	}()
+	assert.Contains(t, e.Serialize(defaultSerializationOptions()), "\nUID:"+e.Id()+"\n")
+
+	e.SetDescription(strings.Repeat("long ", 20))
+	assert.NotContains(t, e.Serialize(defaultSerializationOptions()), strings.Repeat("long ", 20))
+}
+
+func TestSortedParameters(t *testing.T) {
+	e := NewEvent("test-sorted-parameters")
+	e.AddAttendee("a@example.com", WithRSVP(true), WithCN("A"), ParticipationRoleReqParticipant, WithScheduleStatus("2.0", "3.7"))
+	p := e.GetProperty(ComponentPropertyAttendee)
+	assert.Equal(t, []KeyValues{
+		{Key: "CN", Value: []string{"A"}},
+		{Key: "ROLE", Value: []string{"REQ-PARTICIPANT"}},
+		{Key: "RSVP", Value: []string{"true"}},
+		{Key: "SCHEDULE-STATUS", Value: []string{"2.0", "3.7"}},
+	}, p.SortedParameters())
+	assert.Empty(t, (&BaseProperty{}).SortedParameters())
+}
@@ -0,0 +1,1175 @@
+package ics
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRecurrencePeriods bounds the number of FREQ periods examined while expanding a single rule, protecting against
+// rules which can never (or very rarely) produce an instance.
+const maxRecurrencePeriods = 500000
+
+type weekdayNum struct {
+	n   int
+	day time.Weekday
+}
+
+// recurrenceRule is a parsed RECUR value
+// https://www.rfc-editor.org/rfc/rfc5545#section-3.3.10
+type recurrenceRule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	bySecond   []int
+	byMinute   []int
+	byHour     []int
+	byDay      []weekdayNum
+	byMonthDay []int
+	byYearDay  []int
+	byMonth    []int
+	bySetPos   []int
+	wkst       time.Weekday
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// parseRecurrenceRule parses an RRULE or EXRULE value. Floating and date UNTIL values are interpreted in the location of
+// dtstart.
+func parseRecurrenceRule(s string, dtstart time.Time) (*recurrenceRule, error) {
+	r := &recurrenceRule{
+		interval: 1,
+		wkst:     time.Monday,
+	}
+	for _, part := range strings.Split(s, ";") {
+		if part == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("recurrence rule part %q: expected name=value", part)
+		}
+		var err error
+		switch strings.ToUpper(k) {
+		case "FREQ":
+			r.freq = strings.ToUpper(v)
+			switch r.freq {
+			case "SECONDLY", "MINUTELY", "HOURLY", "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+			default:
+				err = fmt.Errorf("unknown frequency %q", v)
+			}
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(v)
+			if err == nil && r.interval < 1 {
+				err = errors.New("interval must be positive")
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(v)
+			if err == nil && r.count < 1 {
+				err = errors.New("count must be positive")
+			}
+		case "UNTIL":
+			r.until, err = parseUntil(v, dtstart.Location())
+		case "BYSECOND":
+			r.bySecond, err = parseIntList(v, 0, 60, false)
+		case "BYMINUTE":
+			r.byMinute, err = parseIntList(v, 0, 59, false)
+		case "BYHOUR":
+			r.byHour, err = parseIntList(v, 0, 23, false)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseIntList(v, 1, 31, true)
+		case "BYYEARDAY":
+			r.byYearDay, err = parseIntList(v, 1, 366, true)
+		case "BYMONTH":
+			r.byMonth, err = parseIntList(v, 1, 12, false)
+		case "BYSETPOS":
+			r.bySetPos, err = parseIntList(v, 1, 366, true)
+		case "BYDAY":
+			r.byDay, err = parseWeekdayList(v)
+		case "WKST":
+			var ok bool
+			r.wkst, ok = weekdays[strings.ToUpper(v)]
+			if !ok {
+				err = fmt.Errorf("unknown weekday %q", v)
+			}
+		case "BYWEEKNO":
+			err = errors.New("not supported")
+		default:
+			if !strings.HasPrefix(strings.ToUpper(k), "X-") {
+				err = errors.New("unknown rule part")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("recurrence rule %s: %w", k, err)
+		}
+	}
+	if r.freq == "" {
+		return nil, errors.New("recurrence rule: FREQ is required")
+	}
+	return r, nil
+}
+
+func parseUntil(v string, loc *time.Location) (time.Time, error) {
+	if t, err := time.ParseInLocation(icalTimestampFormatUtc, v, time.UTC); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(icalTimestampFormatLocal, v, loc); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(icalDateFormatLocal, v, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse %q", v)
+	}
+	// A date includes every instance on that day
+	return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+func parseIntList(v string, min, max int, allowNegative bool) ([]int, error) {
+	var r []int
+	for _, s := range strings.Split(v, ",") {
+		i, err := strconv.Atoi(strings.TrimPrefix(s, "+"))
+		if err != nil {
+			return nil, err
+		}
+		a := i
+		if a < 0 && allowNegative {
+			a = -a
+		}
+		if a < min || a > max {
+			return nil, fmt.Errorf("%d out of range", i)
+		}
+		r = append(r, i)
+	}
+	return r, nil
+}
+
+func parseWeekdayList(v string) ([]weekdayNum, error) {
+	var r []weekdayNum
+	for _, s := range strings.Split(v, ",") {
+		s = strings.ToUpper(s)
+		if len(s) < 2 {
+			return nil, fmt.Errorf("unknown weekday %q", s)
+		}
+		day, ok := weekdays[s[len(s)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", s)
+		}
+		wn := weekdayNum{day: day}
+		if n := strings.TrimPrefix(s[:len(s)-2], "+"); n != "" {
+			var err error
+			wn.n, err = strconv.Atoi(n)
+			if err != nil || wn.n == 0 || wn.n > 53 || wn.n < -53 {
+				return nil, fmt.Errorf("invalid weekday ordinal %q", s)
+			}
+		}
+		r = append(r, wn)
+	}
+	return r, nil
+}
+
+func (r *recurrenceRule) bounded() bool {
+	return r.count > 0 || !r.until.IsZero()
+}
+
+// expand calls yield for each instance start of the rule in order, beginning with dtstart which always counts as the
+// first instance. Expansion stops at COUNT, UNTIL, the first instance not before end (unless end is zero) or when yield
+// returns false.
+func (r *recurrenceRule) expand(dtstart time.Time, end time.Time, yield func(time.Time) bool) error {
+	if end.IsZero() && !r.bounded() {
+		return fmt.Errorf("%w: an end is required to expand a rule without COUNT or UNTIL", ErrorUnboundedRecurrence)
+	}
+	emitted := 0
+	emit := func(t time.Time) bool {
+		if !r.until.IsZero() && t.After(r.until) {
+			return false
+		}
+		if !end.IsZero() && !t.Before(end) {
+			return false
+		}
+		emitted++
+		if !yield(t) {
+			return false
+		}
+		return r.count == 0 || emitted < r.count
+	}
+	if !emit(dtstart) || r.neverMatches() {
+		return nil
+	}
+	for k := 0; k < maxRecurrencePeriods; k++ {
+		p := r.periodStart(dtstart, k)
+		// Instances are never before the start of their period, so once it is past UNTIL or end there are no more
+		if (!r.until.IsZero() && p.After(r.until)) || (!end.IsZero() && !p.Before(end)) {
+			return nil
+		}
+		for _, t := range r.candidates(p, dtstart) {
+			if !t.After(dtstart) {
+				continue
+			}
+			if !emit(t) {
+				return nil
+			}
+		}
+	}
+	return errors.New("recurrence rule: expansion limit reached")
+}
+
+// monthDays is the longest each month can be, indexed by month
+var monthDays = [...]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// neverMatches returns true if BYMONTHDAY only gives days which none of the months allowed by BYMONTH have, such as
+// BYMONTH=2;BYMONTHDAY=30, so no period would ever yield an instance.
+func (r *recurrenceRule) neverMatches() bool {
+	if len(r.byMonthDay) == 0 {
+		return false
+	}
+	longest := 31
+	if len(r.byMonth) > 0 {
+		longest = 0
+		for _, m := range r.byMonth {
+			if m >= 1 && m <= 12 && monthDays[m] > longest {
+				longest = monthDays[m]
+			}
+		}
+	}
+	for _, d := range r.byMonthDay {
+		if d != 0 && d <= longest && -d <= longest {
+			return false
+		}
+	}
+	return true
+}
+
+// periodStart returns the start of the k'th FREQ period counted in INTERVAL steps from dtstart's
+func (r *recurrenceRule) periodStart(dtstart time.Time, k int) time.Time {
+	y, m, d := dtstart.Date()
+	loc := dtstart.Location()
+	step := k * r.interval
+	switch r.freq {
+	case "YEARLY":
+		return time.Date(y+step, time.January, 1, 0, 0, 0, 0, loc)
+	case "MONTHLY":
+		return time.Date(y, m+time.Month(step), 1, 0, 0, 0, 0, loc)
+	case "WEEKLY":
+		offset := (int(dtstart.Weekday()) - int(r.wkst) + 7) % 7
+		return time.Date(y, m, d-offset+7*step, 0, 0, 0, 0, loc)
+	case "DAILY":
+		return time.Date(y, m, d+step, 0, 0, 0, 0, loc)
+	case "HOURLY":
+		return time.Date(y, m, d, dtstart.Hour(), 0, 0, 0, loc).Add(time.Duration(step) * time.Hour)
+	case "MINUTELY":
+		return time.Date(y, m, d, dtstart.Hour(), dtstart.Minute(), 0, 0, loc).Add(time.Duration(step) * time.Minute)
+	default:
+		return dtstart.Truncate(time.Second).Add(time.Duration(step) * time.Second)
+	}
+}
+
+// candidates returns the sorted instances within the period starting at p
+func (r *recurrenceRule) candidates(p time.Time, dtstart time.Time) []time.Time {
+	subDaily := r.freq == "HOURLY" || r.freq == "MINUTELY" || r.freq == "SECONDLY"
+	hours := timeParts(r.byHour, dtstart.Hour(), p.Hour(), subDaily)
+	minutes := timeParts(r.byMinute, dtstart.Minute(), p.Minute(), r.freq == "MINUTELY" || r.freq == "SECONDLY")
+	seconds := timeParts(r.bySecond, dtstart.Second(), p.Second(), r.freq == "SECONDLY")
+	var result []time.Time
+	for _, day := range r.days(p, dtstart) {
+		for _, h := range hours {
+			for _, m := range minutes {
+				for _, s := range seconds {
+					result = append(result, time.Date(day.Year(), day.Month(), day.Day(), h, m, s, 0, p.Location()))
+				}
+			}
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Before(result[j])
+	})
+	if len(r.bySetPos) == 0 {
+		return result
+	}
+	var selected []time.Time
+	for _, pos := range r.bySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(result) + pos
+		}
+		if i >= 0 && i < len(result) {
+			selected = append(selected, result[i])
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return selected[i].Before(selected[j])
+	})
+	return selected
+}
+
+// timeParts returns the values for a time of day part. When limiting, the period's own value is only kept if the BYxxx
+// list allows it, otherwise the list expands or dtstart's value is used.
+func timeParts(by []int, dtstartValue int, periodValue int, limit bool) []int {
+	if limit {
+		if len(by) == 0 || containsInt(by, periodValue) {
+			return []int{periodValue}
+		}
+		return nil
+	}
+	if len(by) == 0 {
+		return []int{dtstartValue}
+	}
+	return by
+}
+
+// days returns the dates (at midnight) within the period starting at p which satisfy the day based rule parts
+func (r *recurrenceRule) days(p time.Time, dtstart time.Time) []time.Time {
+	loc := p.Location()
+	y, m, d := p.Date()
+	var result []time.Time
+	switch r.freq {
+	case "YEARLY":
+		hasDayRule := len(r.byYearDay) > 0 || len(r.byMonthDay) > 0 || len(r.byDay) > 0
+		for day := time.Date(y, time.January, 1, 0, 0, 0, 0, loc); day.Year() == y; day = day.AddDate(0, 0, 1) {
+			if len(r.byMonth) > 0 && !containsInt(r.byMonth, int(day.Month())) {
+				continue
+			}
+			var ok bool
+			switch {
+			case !hasDayRule && len(r.byMonth) > 0:
+				ok = day.Day() == dtstart.Day()
+			case !hasDayRule:
+				ok = day.Month() == dtstart.Month() && day.Day() == dtstart.Day()
+			default:
+				ok = r.matchesYearDay(day) && r.matchesMonthDay(day) && r.matchesDay(day, len(r.byMonth) == 0)
+			}
+			if ok {
+				result = append(result, day)
+			}
+		}
+	case "MONTHLY":
+		if len(r.byMonth) > 0 && !containsInt(r.byMonth, int(m)) {
+			return nil
+		}
+		for day := time.Date(y, m, 1, 0, 0, 0, 0, loc); day.Month() == m; day = day.AddDate(0, 0, 1) {
+			var ok bool
+			if len(r.byMonthDay) == 0 && len(r.byDay) == 0 {
+				ok = day.Day() == dtstart.Day()
+			} else {
+				ok = r.matchesMonthDay(day) && r.matchesDay(day, false)
+			}
+			if ok {
+				result = append(result, day)
+			}
+		}
+	case "WEEKLY":
+		for i := 0; i < 7; i++ {
+			day := time.Date(y, m, d+i, 0, 0, 0, 0, loc)
+			if len(r.byMonth) > 0 && !containsInt(r.byMonth, int(day.Month())) {
+				continue
+			}
+			if len(r.byDay) == 0 && day.Weekday() != dtstart.Weekday() {
+				continue
+			}
+			if r.matchesDay(day, false) {
+				result = append(result, day)
+			}
+		}
+	default:
+		day := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		if (len(r.byMonth) == 0 || containsInt(r.byMonth, int(m))) && r.matchesYearDay(day) && r.matchesMonthDay(day) &&
+			r.matchesDay(day, false) {
+			result = append(result, day)
+		}
+	}
+	return result
+}
+
+func (r *recurrenceRule) matchesMonthDay(day time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	daysInMonth := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	for _, v := range r.byMonthDay {
+		if (v > 0 && day.Day() == v) || (v < 0 && day.Day() == daysInMonth+v+1) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *recurrenceRule) matchesYearDay(day time.Time) bool {
+	if len(r.byYearDay) == 0 {
+		return true
+	}
+	daysInYear := time.Date(day.Year(), time.December, 31, 0, 0, 0, 0, day.Location()).YearDay()
+	for _, v := range r.byYearDay {
+		if (v > 0 && day.YearDay() == v) || (v < 0 && day.YearDay() == daysInYear+v+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDay checks BYDAY. Ordinals are relative to the year when inYear is set, otherwise to the month, and are only
+// meaningful for MONTHLY and YEARLY rules.
+func (r *recurrenceRule) matchesDay(day time.Time, inYear bool) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	index, length := day.Day()-1, time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+	if inYear {
+		index, length = day.YearDay()-1, time.Date(day.Year(), time.December, 31, 0, 0, 0, 0, day.Location()).YearDay()
+	}
+	ordinals := r.freq == "MONTHLY" || r.freq == "YEARLY"
+	for _, wd := range r.byDay {
+		if wd.day != day.Weekday() {
+			continue
+		}
+		if wd.n == 0 || !ordinals {
+			return true
+		}
+		if wd.n == index/7+1 || wd.n == -((length-index-1)/7+1) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, v int) bool {
+	for _, i := range list {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTimes parses each of the comma separated values of a date or date-time list property such as EXDATE or RDATE.
+// PERIOD values yield their start.
+func (bp *BaseProperty) parseTimes() ([]time.Time, error) {
+	var r []time.Time
+	for _, v := range strings.Split(bp.Value, ",") {
+		vp := *bp
+		vp.Value, _, _ = strings.Cut(v, "/")
+		t, err := vp.parseTime(false)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, t)
+	}
+	return r, nil
+}
+
+// RecurrenceKind classifies an event's part in a recurring series, see VEvent.RecurrenceKind
+type RecurrenceKind string
+
+const (
+	// RecurrenceKindSingle is an event which neither recurs nor overrides an instance of a series
+	RecurrenceKindSingle RecurrenceKind = "SINGLE"
+	// RecurrenceKindMaster is an event with an RRULE or RDATE defining a series
+	RecurrenceKindMaster RecurrenceKind = "MASTER"
+	// RecurrenceKindOverride is an event with a RECURRENCE-ID replacing instances of the series sharing its UID
+	RecurrenceKindOverride RecurrenceKind = "OVERRIDE"
+)
+
+// RecurrenceKind returns whether the event overrides an instance of a series, which takes precedence, is the master of
+// a series or is a single event.
+func (event *VEvent) RecurrenceKind() RecurrenceKind {
+	switch {
+	case event.HasProperty(ComponentPropertyRecurrenceId):
+		return RecurrenceKindOverride
+	case event.HasProperty(ComponentPropertyRrule), event.HasProperty(ComponentPropertyRdate):
+		return RecurrenceKindMaster
+	default:
+		return RecurrenceKindSingle
+	}
+}
+
+// Occurrence is a single instance of an event
+type Occurrence struct {
+	// Event supplies the details of the instance, either the recurring event itself or an override of this instance
+	Event *VEvent
+	Start time.Time
+	End   time.Time
+	// RecurrenceID is the start of the instance as generated by the recurring event, before any override
+	RecurrenceID time.Time
+}
+
+// occurrenceEnd returns a function giving the end of an instance starting at t, from the length of the event as given
+// by DTEND or DURATION. Without either, date events last a day and date-time events have no duration.
+func (event *VEvent) occurrenceEnd() (func(t time.Time) time.Time, error) {
+	start, err := event.GetStartAt()
+	if err != nil {
+		return nil, err
+	}
+	allDay := event.isAllDay()
+	if event.HasProperty(ComponentPropertyDuration) && !event.HasProperty(ComponentPropertyDtEnd) {
+		d, err := event.GetDurationProperty()
+		if err != nil {
+			return nil, err
+		}
+		return d.AddTo, nil
+	}
+	end, err := event.GetEndAt()
+	switch {
+	case errors.Is(err, ErrorPropertyNotFound) && allDay:
+		return func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }, nil
+	case errors.Is(err, ErrorPropertyNotFound):
+		return func(t time.Time) time.Time { return t }, nil
+	case err != nil:
+		return nil, err
+	case allDay:
+		days := int(end.Sub(start).Round(24*time.Hour) / (24 * time.Hour))
+		return func(t time.Time) time.Time { return t.AddDate(0, 0, days) }, nil
+	}
+	length := end.Sub(start)
+	return func(t time.Time) time.Time { return t.Add(length) }, nil
+}
+
+func (cb *ComponentBase) isAllDay() bool {
+	p := cb.GetProperty(ComponentPropertyDtStart)
+	return p != nil && p.GetValueType() == ValueDataTypeDate
+}
+
+// occurrenceStarts returns the sorted distinct instance starts before end from DTSTART, RRULE and RDATE less those
+// excluded by EXRULE and EXDATE.
+func (event *VEvent) occurrenceStarts(end time.Time) ([]time.Time, error) {
+	dtstart, err := event.GetStartAt()
+	if err != nil {
+		return nil, err
+	}
+	starts := []time.Time{dtstart}
+	for _, p := range event.GetProperties(ComponentPropertyRrule) {
+		rule, err := parseRecurrenceRule(p.Value, dtstart)
+		if err != nil {
+			return nil, err
+		}
+		if err := rule.expand(dtstart, end, func(t time.Time) bool {
+			starts = append(starts, t)
+			return true
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range event.GetProperties(ComponentPropertyRdate) {
+		ts, err := p.parseTimes()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range ts {
+			if end.IsZero() || t.Before(end) {
+				starts = append(starts, t)
+			}
+		}
+	}
+	excluded, excludedDates, err := event.exclusions(dtstart, end)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i].Before(starts[j])
+	})
+	var result []time.Time
+	for i, t := range starts {
+		if i > 0 && t.Equal(starts[i-1]) {
+			continue
+		}
+		if isExcluded(t, excluded, excludedDates) {
+			continue
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// exclusions returns the instance starts excluded by EXRULE and EXDATE before end, along with the dates on which all
+// instances are excluded by date valued EXDATEs of a date-time event.
+func (event *VEvent) exclusions(dtstart time.Time, end time.Time) ([]time.Time, []time.Time, error) {
+	var excluded []time.Time
+	var excludedDates []time.Time
+	for _, p := range event.GetProperties(ComponentPropertyExrule) {
+		rule, err := parseRecurrenceRule(p.Value, dtstart)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := rule.expand(dtstart, end, func(t time.Time) bool {
+			excluded = append(excluded, t)
+			return true
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, p := range event.GetProperties(ComponentPropertyExdate) {
+		ts, err := p.parseTimes()
+		if err != nil {
+			return nil, nil, err
+		}
+		if p.GetValueType() == ValueDataTypeDate && !event.isAllDay() {
+			excludedDates = append(excludedDates, ts...)
+		} else {
+			excluded = append(excluded, ts...)
+		}
+	}
+	return excluded, excludedDates, nil
+}
+
+func isExcluded(t time.Time, excluded []time.Time, excludedDates []time.Time) bool {
+	for _, e := range excluded {
+		if e.Equal(t) {
+			return true
+		}
+	}
+	y, m, d := t.Date()
+	for _, e := range excludedDates {
+		ey, em, ed := e.Date()
+		if ey == y && em == m && ed == d {
+			return true
+		}
+	}
+	return false
+}
+
+// CompactExdates merges the EXDATE properties sharing a value type and TZID into a single comma separated EXDATE, in
+// place of the first, dropping values excluding an instance already excluded. The event is left unchanged if any EXDATE
+// can not be parsed.
+func (event *VEvent) CompactExdates() error {
+	type exdateGroup struct {
+		index  int
+		values []string
+		times  []time.Time
+	}
+	groups := map[string]*exdateGroup{}
+	var properties []IANAProperty
+	for _, p := range event.Properties {
+		if p.IANAToken != string(ComponentPropertyExdate) {
+			properties = append(properties, p)
+			continue
+		}
+		ts, err := p.parseTimes()
+		if err != nil {
+			return err
+		}
+		tzid, _ := p.parameterValue(ParameterTzid)
+		key := string(p.GetValueType()) + ";" + tzid
+		g, ok := groups[key]
+		if !ok {
+			g = &exdateGroup{index: len(properties)}
+			groups[key] = g
+			properties = append(properties, p)
+		}
+		for i, v := range strings.Split(p.Value, ",") {
+			if !isExcluded(ts[i], g.times, nil) {
+				g.times = append(g.times, ts[i])
+				g.values = append(g.values, v)
+			}
+		}
+	}
+	for _, g := range groups {
+		properties[g.index].Value = strings.Join(g.values, ",")
+	}
+	event.Properties = properties
+	return nil
+}
+
+// Occurrences returns the instances of this event, expanding RRULE, RDATE, EXRULE and EXDATE, which overlap the range
+// from (inclusive) to (exclusive). Overrides of instances are separate events, use Calendar.Occurrences to have them
+// applied. Supports all of RFC5545 recurrence rules except BYWEEKNO. A zero to means no upper bound, which is only
+// accepted when every RRULE and EXRULE has a COUNT or UNTIL, otherwise an error wrapping ErrorUnboundedRecurrence is
+// returned; use OccurrencesLimited for the next instances of a series which never ends.
+func (event *VEvent) Occurrences(from, to time.Time) ([]Occurrence, error) {
+	starts, err := event.occurrenceStarts(to)
+	if err != nil {
+		return nil, err
+	}
+	endOf, err := event.occurrenceEnd()
+	if err != nil {
+		return nil, err
+	}
+	var r []Occurrence
+	for _, start := range starts {
+		o := Occurrence{
+			Event:        event,
+			Start:        start,
+			End:          endOf(start),
+			RecurrenceID: start,
+		}
+		if o.overlaps(from, to) {
+			r = append(r, o)
+		}
+	}
+	return r, nil
+}
+
+// LastOccurrence returns the start of the final instance of the event after EXDATE and EXRULE exclusions, such as for
+// showing when a series ends. ok is false if an RRULE without COUNT or UNTIL means the series never ends, or if every
+// instance is excluded. An event which does not recur has DTSTART as its last occurrence.
+func (event *VEvent) LastOccurrence() (last time.Time, ok bool, err error) {
+	starts, err := event.occurrenceStarts(time.Time{})
+	if errors.Is(err, ErrorUnboundedRecurrence) {
+		return time.Time{}, false, nil
+	}
+	if err != nil || len(starts) == 0 {
+		return time.Time{}, false, err
+	}
+	return starts[len(starts)-1], true, nil
+}
+
+// maxLimitedWindowDays bounds how far OccurrencesLimited looks ahead for instances of a series which never ends.
+const maxLimitedWindowDays = 1 << 20
+
+// ExpandToComponents returns the instances of this event overlapping the range, as given by Occurrences, each as a copy
+// of the event without RRULE, RDATE, EXRULE, EXDATE or DURATION, with a DTSTART and DTEND in the form of the original
+// DTSTART, for consumers which do not understand recurrence. Copies of a recurring event share its UID and have a
+// RECURRENCE-ID identifying their instance. Overrides of instances elsewhere in the calendar are not applied.
+func (event *VEvent) ExpandToComponents(from, to time.Time) ([]*VEvent, error) {
+	occurrences, err := event.Occurrences(from, to)
+	if err != nil {
+		return nil, err
+	}
+	recurs := event.RecurrenceKind() == RecurrenceKindMaster
+	r := make([]*VEvent, 0, len(occurrences))
+	for _, o := range occurrences {
+		e := CloneComponent(event).(*VEvent)
+		for _, cp := range []ComponentProperty{ComponentPropertyRrule, ComponentPropertyRdate, ComponentPropertyExrule, ComponentPropertyExdate, ComponentPropertyDuration} {
+			e.RemoveProperty(cp)
+		}
+		v, params := event.formatLikeStart(o.Start)
+		e.SetProperty(ComponentPropertyDtStart, v, params...)
+		v, params = event.formatLikeStart(o.End)
+		e.SetProperty(ComponentPropertyDtEnd, v, params...)
+		if recurs {
+			v, params = event.formatLikeStart(o.RecurrenceID)
+			e.SetProperty(ComponentPropertyRecurrenceId, v, params...)
+		}
+		r = append(r, e)
+	}
+	return r, nil
+}
+
+// OccurrencesLimited returns at most max instances of this event overlapping from onwards, as Occurrences would. Unlike
+// Occurrences with a zero to, it accepts rules without COUNT or UNTIL, expanding a widening window until enough
+// instances are found.
+func (event *VEvent) OccurrencesLimited(from time.Time, max int) ([]Occurrence, error) {
+	if max <= 0 {
+		return nil, nil
+	}
+	r, err := event.Occurrences(from, time.Time{})
+	if err == nil {
+		if len(r) > max {
+			r = r[:max]
+		}
+		return r, nil
+	}
+	if !errors.Is(err, ErrorUnboundedRecurrence) {
+		return nil, err
+	}
+	base := from
+	if dtstart, err := event.GetStartAt(); err == nil && dtstart.After(base) {
+		base = dtstart
+	}
+	for days := 7; ; days *= 2 {
+		r, err = event.Occurrences(from, base.AddDate(0, 0, days))
+		if err != nil {
+			return nil, err
+		}
+		if len(r) >= max {
+			return r[:max], nil
+		}
+		if days >= maxLimitedWindowDays {
+			return r, nil
+		}
+	}
+}
+
+// CountOccurrences returns the number of instances of this event overlapping the range from (inclusive) to (exclusive),
+// as len(Occurrences(from, to)) would, but for the usual single RRULE without RDATEs counts while expanding without
+// collecting the instances.
+func (event *VEvent) CountOccurrences(from, to time.Time) (int, error) {
+	rrules := event.GetProperties(ComponentPropertyRrule)
+	if len(rrules) != 1 || event.HasProperty(ComponentPropertyRdate) {
+		occurrences, err := event.Occurrences(from, to)
+		return len(occurrences), err
+	}
+	dtstart, err := event.GetStartAt()
+	if err != nil {
+		return 0, err
+	}
+	endOf, err := event.occurrenceEnd()
+	if err != nil {
+		return 0, err
+	}
+	rule, err := parseRecurrenceRule(rrules[0].Value, dtstart)
+	if err != nil {
+		return 0, err
+	}
+	excluded, excludedDates, err := event.exclusions(dtstart, to)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	err = rule.expand(dtstart, to, func(t time.Time) bool {
+		if !isExcluded(t, excluded, excludedDates) && (Occurrence{Start: t, End: endOf(t)}).overlaps(from, to) {
+			n++
+		}
+		return true
+	})
+	return n, err
+}
+
+func (o Occurrence) overlaps(from, to time.Time) bool {
+	if !to.IsZero() && !o.Start.Before(to) {
+		return false
+	}
+	if o.End.Equal(o.Start) {
+		return !o.Start.Before(from)
+	}
+	return o.End.After(from)
+}
+
+// Occurrences returns the instances of every event in the calendar overlapping the range from (inclusive) to
+// (exclusive) in start order. Events sharing a UID are treated as a series, with those carrying a RECURRENCE-ID
+// replacing the matching instance of the recurring event, or with RANGE=THISANDFUTURE that instance and all those after.
+func (cal *Calendar) Occurrences(from, to time.Time) ([]Occurrence, error) {
+	overrides := map[string][]*VEvent{}
+	masters := map[string]bool{}
+	for _, event := range cal.Events() {
+		if event.HasProperty(ComponentPropertyRecurrenceId) {
+			overrides[event.Id()] = append(overrides[event.Id()], event)
+		} else {
+			masters[event.Id()] = true
+		}
+	}
+	var r []Occurrence
+	for _, event := range cal.Events() {
+		if event.HasProperty(ComponentPropertyRecurrenceId) {
+			if masters[event.Id()] {
+				continue
+			}
+			occurrences, err := event.overrideOccurrence(from, to)
+			if err != nil {
+				return nil, fmt.Errorf("event %s: %w", event.Id(), err)
+			}
+			r = append(r, occurrences...)
+			continue
+		}
+		occurrences, err := event.seriesOccurrences(overrides[event.Id()], from, to)
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", event.Id(), err)
+		}
+		r = append(r, occurrences...)
+	}
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].Start.Before(r[j].Start)
+	})
+	return r, nil
+}
+
+// overrideOccurrence returns the instance described by an override event if it overlaps the range
+func (event *VEvent) overrideOccurrence(from, to time.Time) ([]Occurrence, error) {
+	recurrenceId, err := event.getTimeProp(ComponentPropertyRecurrenceId, false)
+	if err != nil {
+		return nil, err
+	}
+	occurrences, err := event.Occurrences(from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range occurrences {
+		occurrences[i].RecurrenceID = recurrenceId
+	}
+	return occurrences, nil
+}
+
+// rangeOverride is an override with a RANGE applying to a span of instances rather than just its own
+type rangeOverride struct {
+	event        *VEvent
+	recurrenceId time.Time
+	prior        bool
+	offset       time.Duration
+	endOf        func(time.Time) time.Time
+}
+
+// seriesOccurrences returns the instances of a recurring event with its overrides applied. An override replaces the
+// instance whose start matches its RECURRENCE-ID. With RANGE=THISANDFUTURE (or the deprecated THISANDPRIOR) it also
+// applies to all later (or earlier) instances, moving each by the change in start time it makes to its own instance and
+// giving each its duration.
+func (event *VEvent) seriesOccurrences(overrides []*VEvent, from, to time.Time) ([]Occurrence, error) {
+	var r []Occurrence
+	var recurrenceIds []time.Time
+	var ranged []rangeOverride
+	var widen time.Duration
+	for _, override := range overrides {
+		recurrenceId, err := override.getTimeProp(ComponentPropertyRecurrenceId, false)
+		if err != nil {
+			return nil, err
+		}
+		var rr RecurrenceRange
+		if v := override.GetProperty(ComponentPropertyRecurrenceId).ICalParameters[string(ParameterRange)]; len(v) > 0 {
+			rr = RecurrenceRange(strings.ToUpper(v[0]))
+		}
+		if rr != RecurrenceRangeThisAndFuture && rr != RecurrenceRangeThisAndPrior {
+			o, err := override.overrideOccurrence(from, to)
+			if err != nil {
+				return nil, err
+			}
+			recurrenceIds = append(recurrenceIds, recurrenceId)
+			r = append(r, o...)
+			continue
+		}
+		start, err := override.GetStartAt()
+		if err != nil {
+			return nil, err
+		}
+		endOf, err := override.occurrenceEnd()
+		if err != nil {
+			return nil, err
+		}
+		ro := rangeOverride{
+			event:        override,
+			recurrenceId: recurrenceId,
+			prior:        rr == RecurrenceRangeThisAndPrior,
+			offset:       start.Sub(recurrenceId),
+			endOf:        endOf,
+		}
+		ranged = append(ranged, ro)
+		if w := absDuration(ro.offset) + endOf(start).Sub(start); w > widen {
+			widen = w
+		}
+	}
+	// Instances moved by a ranged override may come from outside the range
+	expandTo := to
+	if !to.IsZero() {
+		expandTo = to.Add(widen)
+	}
+	occurrences, err := event.Occurrences(from.Add(-widen), expandTo)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range occurrences {
+		if isExcluded(o.RecurrenceID, recurrenceIds, nil) {
+			continue
+		}
+		if ro := closestRangeOverride(ranged, o.RecurrenceID); ro != nil {
+			o.Event = ro.event
+			o.Start = o.RecurrenceID.Add(ro.offset)
+			o.End = ro.endOf(o.Start)
+		}
+		if o.overlaps(from, to) {
+			r = append(r, o)
+		}
+	}
+	return r, nil
+}
+
+// closestRangeOverride returns the ranged override nearest to the instance which covers it, if any
+func closestRangeOverride(ranged []rangeOverride, recurrenceId time.Time) *rangeOverride {
+	var closest *rangeOverride
+	for i, ro := range ranged {
+		if (ro.prior && ro.recurrenceId.Before(recurrenceId)) || (!ro.prior && ro.recurrenceId.After(recurrenceId)) {
+			continue
+		}
+		if closest == nil || absDuration(recurrenceId.Sub(ro.recurrenceId)) < absDuration(recurrenceId.Sub(closest.recurrenceId)) {
+			closest = &ranged[i]
+		}
+	}
+	return closest
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// AlarmOccurrence is a time an alarm fires for an instance of an event
+type AlarmOccurrence struct {
+	Event      *VEvent
+	Alarm      *VAlarm
+	Occurrence Occurrence
+	FireTime   time.Time
+}
+
+// UpcomingAlarms returns every firing of the calendar's event alarms from (inclusive) to (exclusive), in fire time order.
+// Recurring events are expanded with their overrides applied, and alarms repeated by REPEAT and DURATION are included.
+// An alarm with an absolute trigger fires once however many instances its event has. Events and alarms which can not be
+// read are skipped.
+func (cal *Calendar) UpcomingAlarms(from, to time.Time) []AlarmOccurrence {
+	// Widen the range so instances whose alarms fire well before or after them are included
+	var widen time.Duration
+	for _, event := range cal.Events() {
+		for _, alarm := range event.Alarms() {
+			if w := alarm.maxOffset(); w > widen {
+				widen = w
+			}
+		}
+	}
+	occurrences, err := cal.Occurrences(from.Add(-widen), to.Add(widen))
+	if err != nil {
+		occurrences = nil
+		for _, event := range cal.Events() {
+			o, err := event.Occurrences(from.Add(-widen), to.Add(widen))
+			if err == nil {
+				occurrences = append(occurrences, o...)
+			}
+		}
+	}
+	var r []AlarmOccurrence
+	absolute := map[*VAlarm]bool{}
+	for _, o := range occurrences {
+		for _, alarm := range o.Event.Alarms() {
+			fire, err := alarm.TriggerTimeFor(o.Event, o.Start)
+			if err != nil {
+				continue
+			}
+			if trigger := alarm.GetProperty(ComponentPropertyTrigger); trigger.GetValueType() == ValueDataTypeDateTime {
+				if absolute[alarm] {
+					continue
+				}
+				absolute[alarm] = true
+			}
+			for _, t := range alarm.repeats(fire) {
+				if !t.Before(from) && t.Before(to) {
+					r = append(r, AlarmOccurrence{Event: o.Event, Alarm: alarm, Occurrence: o, FireTime: t})
+				}
+			}
+		}
+	}
+	sort.SliceStable(r, func(i, j int) bool {
+		return r[i].FireTime.Before(r[j].FireTime)
+	})
+	return r
+}
+
+// repeats returns the times the alarm fires given its first fire time, including any REPEAT after DURATION intervals
+func (c *VAlarm) repeats(fire time.Time) []time.Time {
+	r := []time.Time{fire}
+	repeat := c.GetProperty(ComponentPropertyRepeat)
+	duration := c.GetProperty(ComponentPropertyDuration)
+	if repeat == nil || duration == nil {
+		return r
+	}
+	n, err := strconv.Atoi(repeat.Value)
+	if err != nil {
+		return r
+	}
+	d, err := ParseDuration(duration.Value)
+	if err != nil {
+		return r
+	}
+	for i := 0; i < n; i++ {
+		fire = d.AddTo(fire)
+		r = append(r, fire)
+	}
+	return r
+}
+
+// maxOffset returns how far from its instance a relative trigger, including repeats, may fire
+func (c *VAlarm) maxOffset() time.Duration {
+	trigger := c.GetProperty(ComponentPropertyTrigger)
+	if trigger == nil || trigger.GetValueType() == ValueDataTypeDateTime {
+		return 0
+	}
+	d, err := ParseDuration(trigger.Value)
+	if err != nil {
+		return 0
+	}
+	// Allow a day either side for daylight saving and nominal days
+	repeats := c.repeats(time.Time{})
+	return absDuration(d.Duration()) + 24*time.Hour + absDuration(repeats[len(repeats)-1].Sub(time.Time{}))
+}
+
+// Interval is a period of time from Start (inclusive) to End (exclusive)
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// BusyIntervals returns the merged periods of the day containing day, in loc, during which the calendar's events are
+// busy. Events marked TRANSP:TRANSPARENT or STATUS:CANCELLED are ignored and recurring events are expanded. Floating
+// times, including all day events, are taken to be in loc. Events whose times can not be read are skipped.
+func (cal *Calendar) BusyIntervals(day time.Time, loc *time.Location) []Interval {
+	y, m, d := day.In(loc).Date()
+	dayStart := time.Date(y, m, d, 0, 0, 0, 0, loc)
+	dayEnd := time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+	// Floating times are parsed in time.Local, so widen the range to catch them before moving them to loc
+	occurrences, err := cal.Occurrences(dayStart.AddDate(0, 0, -2), dayEnd.AddDate(0, 0, 2))
+	if err != nil {
+		occurrences = nil
+		for _, event := range cal.Events() {
+			o, err := event.Occurrences(dayStart.AddDate(0, 0, -2), dayEnd.AddDate(0, 0, 2))
+			if err == nil {
+				occurrences = append(occurrences, o...)
+			}
+		}
+	}
+	var intervals []Interval
+	for _, o := range occurrences {
+		if !o.Event.isBusy() {
+			continue
+		}
+		start, end := o.Start, o.End
+		if o.Event.isFloating(ComponentPropertyDtStart) {
+			start, end = inLocation(start, loc), inLocation(end, loc)
+		}
+		if start.Before(dayStart) {
+			start = dayStart
+		}
+		if end.After(dayEnd) {
+			end = dayEnd
+		}
+		if end.After(start) {
+			intervals = append(intervals, Interval{Start: start, End: end})
+		}
+	}
+	return mergeIntervals(intervals)
+}
+
+func (event *VEvent) isBusy() bool {
+	if p := event.GetProperty(ComponentPropertyTransp); p != nil && strings.EqualFold(p.Value, string(TransparencyTransparent)) {
+		return false
+	}
+	if p := event.GetProperty(ComponentPropertyStatus); p != nil && strings.EqualFold(p.Value, string(ObjectStatusCancelled)) {
+		return false
+	}
+	return true
+}
+
+// isFloating returns true if the property is a date or date-time not tied to a time zone
+func (cb *ComponentBase) isFloating(cp ComponentProperty) bool {
+	p := cb.GetProperty(cp)
+	if p == nil {
+		return false
+	}
+	_, hasTzid := p.ICalParameters[string(ParameterTzid)]
+	return !hasTzid && !strings.HasSuffix(p.Value, "Z")
+}
+
+// inLocation returns the same wall clock time as t in loc
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+func mergeIntervals(intervals []Interval) []Interval {
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start.Before(intervals[j].Start)
+	})
+	var r []Interval
+	for _, i := range intervals {
+		if len(r) > 0 && !i.Start.After(r[len(r)-1].End) {
+			if i.End.After(r[len(r)-1].End) {
+				r[len(r)-1].End = i.End
+			}
+			continue
+		}
+		r = append(r, i)
+	}
+	return r
+}
@@ -0,0 +1,551 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVEventOccurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		props    string
+		from, to string
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "no rule",
+			props:    "DTSTART:20240101T090000Z\nDTEND:20240101T100000Z",
+			from:     "20240101T000000Z",
+			to:       "20240201T000000Z",
+			expected: []string{"20240101T090000Z"},
+		},
+		{
+			name:     "daily count",
+			props:    "DTSTART:20240101T090000Z\nRRULE:FREQ=DAILY;COUNT=3",
+			from:     "20240101T000000Z",
+			to:       "20250101T000000Z",
+			expected: []string{"20240101T090000Z", "20240102T090000Z", "20240103T090000Z"},
+		},
+		{
+			name:     "weekly byday until",
+			props:    "DTSTART:20240101T090000Z\nRRULE:FREQ=WEEKLY;BYDAY=MO,WE;UNTIL=20240110T090000Z",
+			from:     "20240101T000000Z",
+			to:       "20250101T000000Z",
+			expected: []string{"20240101T090000Z", "20240103T090000Z", "20240108T090000Z", "20240110T090000Z"},
+		},
+		{
+			name:     "monthly last friday",
+			props:    "DTSTART:20240126T090000Z\nRRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=3",
+			from:     "20240101T000000Z",
+			to:       "20250101T000000Z",
+			expected: []string{"20240126T090000Z", "20240223T090000Z", "20240329T090000Z"},
+		},
+		{
+			name:     "monthly skips short months",
+			props:    "DTSTART:20240131T090000Z\nRRULE:FREQ=MONTHLY;COUNT=3",
+			from:     "20240101T000000Z",
+			to:       "20250101T000000Z",
+			expected: []string{"20240131T090000Z", "20240331T090000Z", "20240531T090000Z"},
+		},
+		{
+			name:     "yearly bymonth byday bysetpos",
+			props:    "DTSTART:20231123T090000Z\nRRULE:FREQ=YEARLY;BYMONTH=11;BYDAY=TH;BYSETPOS=4;COUNT=2",
+			from:     "20230101T000000Z",
+			to:       "20260101T000000Z",
+			expected: []string{"20231123T090000Z", "20241128T090000Z"},
+		},
+		{
+			name:     "window and exdate",
+			props:    "DTSTART:20240101T090000Z\nDTEND:20240101T100000Z\nRRULE:FREQ=DAILY\nEXDATE:20240104T090000Z",
+			from:     "20240103T093000Z",
+			to:       "20240106T000000Z",
+			expected: []string{"20240103T090000Z", "20240105T090000Z"},
+		},
+		{
+			name:     "rdate",
+			props:    "DTSTART:20240101T090000Z\nRDATE:20240201T090000Z,20240105T090000Z",
+			from:     "20240101T000000Z",
+			to:       "20250101T000000Z",
+			expected: []string{"20240101T090000Z", "20240105T090000Z", "20240201T090000Z"},
+		},
+		{
+			name:    "unbounded",
+			props:   "DTSTART:20240101T090000Z\nRRULE:FREQ=DAILY",
+			from:    "20240101T000000Z",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported",
+			props:   "DTSTART:20240101T090000Z\nRRULE:FREQ=YEARLY;BYWEEKNO=1",
+			from:    "20240101T000000Z",
+			to:      "20250101T000000Z",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\n" + tt.props + "\nEND:VEVENT\nEND:VCALENDAR\n"))
+			require.NoError(t, err)
+			from, _ := time.Parse(icalTimestampFormatUtc, tt.from)
+			var to time.Time
+			if tt.to != "" {
+				to, _ = time.Parse(icalTimestampFormatUtc, tt.to)
+			}
+			occurrences, err := cal.Events()[0].Occurrences(from, to)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			var got []string
+			for _, o := range occurrences {
+				got = append(got, o.Start.UTC().Format(icalTimestampFormatUtc))
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestCalendarOccurrencesOverride(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+RRULE:FREQ=DAILY;COUNT=3
+SUMMARY:Standup
+END:VEVENT
+BEGIN:VEVENT
+UID:a
+RECURRENCE-ID:20240102T090000Z
+DTSTART:20240102T140000Z
+DTEND:20240102T150000Z
+SUMMARY:Moved
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	occurrences, err := cal.Occurrences(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, occurrences, 3)
+	assert.Equal(t, time.Date(2024, 1, 2, 14, 0, 0, 0, time.UTC), occurrences[1].Start)
+	assert.Equal(t, time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), occurrences[1].RecurrenceID)
+	assert.Equal(t, "Moved", occurrences[1].Event.GetProperty(ComponentPropertySummary).Value)
+}
+
+func TestCalendarOccurrencesThisAndFuture(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+RRULE:FREQ=DAILY;COUNT=4
+SUMMARY:Standup
+END:VEVENT
+BEGIN:VEVENT
+UID:a
+RECURRENCE-ID;RANGE=THISANDFUTURE:20240103T090000Z
+DTSTART:20240103T100000Z
+DTEND:20240103T103000Z
+SUMMARY:Later standup
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	occurrences, err := cal.Occurrences(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 4, 10, 15, 0, 0, time.UTC))
+	require.NoError(t, err)
+	var got []string
+	for _, o := range occurrences {
+		got = append(got, o.Start.Format(icalTimestampFormatUtc)+"/"+o.End.Format(icalTimestampFormatUtc)+" "+o.Event.GetProperty(ComponentPropertySummary).Value)
+	}
+	assert.Equal(t, []string{
+		"20240101T090000Z/20240101T100000Z Standup",
+		"20240102T090000Z/20240102T100000Z Standup",
+		"20240103T100000Z/20240103T103000Z Later standup",
+		"20240104T100000Z/20240104T103000Z Later standup",
+	}, got)
+	assert.Equal(t, time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC), occurrences[3].RecurrenceID)
+}
+
+func TestBusyIntervals(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART:20240102T090000Z
+DTEND:20240102T100000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:b
+DTSTART:20240102T093000Z
+DTEND:20240102T110000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:c
+DTSTART:20240101T230000Z
+DTEND:20240102T010000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:d
+DTSTART:20240102T120000Z
+DTEND:20240102T130000Z
+TRANSP:TRANSPARENT
+END:VEVENT
+BEGIN:VEVENT
+UID:e
+DTSTART:20231226T150000Z
+DTEND:20231226T160000Z
+RRULE:FREQ=WEEKLY
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	intervals := cal.BusyIntervals(time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC), time.UTC)
+	assert.Equal(t, []Interval{
+		{Start: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 2, 11, 0, 0, 0, time.UTC)},
+		{Start: time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 2, 16, 0, 0, 0, time.UTC)},
+	}, intervals)
+}
+
+func TestBusyIntervalsAllDay(t *testing.T) {
+	loc, err := time.LoadLocation("Australia/Sydney")
+	require.NoError(t, err)
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART;VALUE=DATE:20240102
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	intervals := cal.BusyIntervals(time.Date(2024, 1, 2, 0, 0, 0, 0, loc), loc)
+	assert.Equal(t, []Interval{
+		{Start: time.Date(2024, 1, 2, 0, 0, 0, 0, loc), End: time.Date(2024, 1, 3, 0, 0, 0, 0, loc)},
+	}, intervals)
+}
+
+func TestAddExdateMatching(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART;TZID=Europe/Berlin:20240101T090000
+RRULE:FREQ=DAILY;COUNT=3
+END:VEVENT
+BEGIN:VEVENT
+UID:b
+DTSTART;VALUE=DATE:20240101
+RRULE:FREQ=DAILY;COUNT=3
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	timed, allDay := cal.Events()[0], cal.Events()[1]
+
+	timed.AddExdateMatching(time.Date(2024, 1, 2, 8, 0, 0, 0, time.UTC))
+	p := timed.GetProperty(ComponentPropertyExdate)
+	assert.Equal(t, "20240102T090000", p.Value)
+	assert.Equal(t, []string{"Europe/Berlin"}, p.ICalParameters[string(ParameterTzid)])
+	occurrences, err := timed.Occurrences(time.Date(2024, 1, 1, 0, 0, 0, 0, loc), time.Date(2024, 2, 1, 0, 0, 0, 0, loc))
+	require.NoError(t, err)
+	assert.Len(t, occurrences, 2)
+
+	allDay.AddExdateMatching(time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+	p = allDay.GetProperty(ComponentPropertyExdate)
+	assert.Equal(t, "20240103", p.Value)
+	assert.Equal(t, []string{"DATE"}, p.ICalParameters[string(ParameterValue)])
+}
+
+func TestCompactExdates(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART;TZID=Europe/Berlin:20240101T090000
+RRULE:FREQ=DAILY;COUNT=10
+EXDATE;TZID=Europe/Berlin:20240102T090000
+SUMMARY:Stand up
+EXDATE;TZID=Europe/Berlin:20240103T090000,20240102T090000
+EXDATE:20240104T080000Z
+EXDATE;TZID=Europe/Berlin:20240105T090000
+EXDATE;VALUE=DATE:20240106
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	event := cal.Events()[0]
+	before, err := event.CountOccurrences(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	require.NoError(t, event.CompactExdates())
+	assert.Equal(t, []string{"20240102T090000,20240103T090000,20240105T090000", "20240104T080000Z", "20240106"}, event.Exdates())
+	assert.Equal(t, string(ComponentPropertySummary), event.Properties[4].IANAToken)
+	after, err := event.CountOccurrences(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	event.AddExdate("bad")
+	assert.Error(t, event.CompactExdates())
+	assert.Len(t, event.Exdates(), 4)
+}
+
+func TestExpandToComponents(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART;TZID=Europe/Berlin:20240101T090000
+DURATION:PT30M
+RRULE:FREQ=DAILY;COUNT=5
+EXDATE;TZID=Europe/Berlin:20240102T090000
+SUMMARY:Stand up
+END:VEVENT
+BEGIN:VEVENT
+UID:b
+DTSTART;VALUE=DATE:20240101
+SUMMARY:Holiday
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	from, to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC)
+
+	expanded, err := cal.Events()[0].ExpandToComponents(from, to)
+	require.NoError(t, err)
+	require.Len(t, expanded, 2)
+	assert.Equal(t, `BEGIN:VEVENT
+UID:a
+DTSTART;TZID=Europe/Berlin:20240103T090000
+SUMMARY:Stand up
+DTEND;TZID=Europe/Berlin:20240103T093000
+RECURRENCE-ID;TZID=Europe/Berlin:20240103T090000
+END:VEVENT
+`, strings.ReplaceAll(expanded[1].Serialize(defaultSerializationOptions()), "\r\n", "\n"))
+	assert.Len(t, cal.Events()[0].Exdates(), 1)
+
+	expanded, err = cal.Events()[1].ExpandToComponents(from, to)
+	require.NoError(t, err)
+	require.Len(t, expanded, 1)
+	assert.Equal(t, "20240102", expanded[0].GetProperty(ComponentPropertyDtEnd).Value)
+	assert.False(t, expanded[0].HasProperty(ComponentPropertyRecurrenceId))
+}
+
+func TestAddRdateMatching(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART;TZID=Europe/Berlin:20240101T090000
+RRULE:FREQ=DAILY;COUNT=2
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	event := cal.Events()[0]
+	event.AddRdateMatching(time.Date(2024, 1, 10, 14, 0, 0, 0, time.UTC))
+	p := event.GetProperty(ComponentPropertyRdate)
+	assert.Equal(t, "20240110T150000", p.Value)
+	assert.Equal(t, []string{"Europe/Berlin"}, p.ICalParameters[string(ParameterTzid)])
+	occurrences, err := event.Occurrences(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, occurrences, 3)
+	assert.True(t, occurrences[2].Start.Equal(time.Date(2024, 1, 10, 14, 0, 0, 0, time.UTC)))
+}
+
+func TestCountOccurrences(t *testing.T) {
+	for _, props := range []string{
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;UNTIL=20240331T235959Z\nEXDATE:20240101T090000Z,20240102T090000Z",
+		"RRULE:FREQ=DAILY;COUNT=40\nRDATE:20240601T090000Z",
+	} {
+		cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nDTSTART:20240101T090000Z\nDTEND:20240101T091500Z\n" + props + "\nEND:VEVENT\nEND:VCALENDAR\n"))
+		require.NoError(t, err)
+		event := cal.Events()[0]
+		from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+		occurrences, err := event.Occurrences(from, to)
+		require.NoError(t, err)
+		n, err := event.CountOccurrences(from, to)
+		require.NoError(t, err)
+		assert.Equal(t, len(occurrences), n)
+	}
+
+	cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nDTSTART:20240101T090000Z\nRRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR;COUNT=65\nEXDATE:20240101T090000Z\nEND:VEVENT\nEND:VCALENDAR\n"))
+	require.NoError(t, err)
+	n, err := cal.Events()[0].CountOccurrences(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 64, n)
+}
+
+func TestRecurrenceKind(t *testing.T) {
+	for _, tt := range []struct {
+		props string
+		want  RecurrenceKind
+	}{
+		{"", RecurrenceKindSingle},
+		{"RRULE:FREQ=DAILY", RecurrenceKindMaster},
+		{"RDATE:20240105T090000Z", RecurrenceKindMaster},
+		{"RECURRENCE-ID:20240102T090000Z", RecurrenceKindOverride},
+		{"RRULE:FREQ=DAILY\nRECURRENCE-ID:20240102T090000Z", RecurrenceKindOverride},
+	} {
+		cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nDTSTART:20240101T090000Z\n" + tt.props + "\nEND:VEVENT\nEND:VCALENDAR\n"))
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, cal.Events()[0].RecurrenceKind(), tt.props)
+	}
+}
+
+func TestLastOccurrence(t *testing.T) {
+	for _, tt := range []struct {
+		props string
+		want  string
+		ok    bool
+	}{
+		{"", "20240101T090000Z", true},
+		{"RRULE:FREQ=DAILY;COUNT=5", "20240105T090000Z", true},
+		{"RRULE:FREQ=DAILY;COUNT=5\nEXDATE:20240105T090000Z,20240104T090000Z", "20240103T090000Z", true},
+		{"RRULE:FREQ=WEEKLY;UNTIL=20240131T235959Z", "20240129T090000Z", true},
+		{"RRULE:FREQ=DAILY;COUNT=2\nRDATE:20240301T090000Z", "20240301T090000Z", true},
+		{"RRULE:FREQ=DAILY;COUNT=1\nEXDATE:20240101T090000Z", "", false},
+		{"RRULE:FREQ=WEEKLY", "", false},
+	} {
+		cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nDTSTART:20240101T090000Z\n" + tt.props + "\nEND:VEVENT\nEND:VCALENDAR\n"))
+		require.NoError(t, err)
+		last, ok, err := cal.Events()[0].LastOccurrence()
+		require.NoError(t, err, tt.props)
+		assert.Equal(t, tt.ok, ok, tt.props)
+		if tt.ok {
+			assert.Equal(t, tt.want, last.UTC().Format(icalTimestampFormatUtc), tt.props)
+		}
+	}
+}
+
+func TestOccurrencesLimited(t *testing.T) {
+	parse := func(rrule string) *VEvent {
+		cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\nDTSTART:20240101T090000Z\nDTEND:20240101T100000Z\n" + rrule + "\nEND:VEVENT\nEND:VCALENDAR\n"))
+		require.NoError(t, err)
+		return cal.Events()[0]
+	}
+	from := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	event := parse("RRULE:FREQ=MONTHLY;BYDAY=-1FR")
+	_, err := event.Occurrences(from, time.Time{})
+	assert.ErrorIs(t, err, ErrorUnboundedRecurrence)
+	occurrences, err := event.OccurrencesLimited(from, 3)
+	require.NoError(t, err)
+	var starts []string
+	for _, o := range occurrences {
+		starts = append(starts, o.Start.Format(icalTimestampFormatUtc))
+	}
+	assert.Equal(t, []string{"20300125T090000Z", "20300222T090000Z", "20300329T090000Z"}, starts)
+
+	occurrences, err = parse("RRULE:FREQ=YEARLY;COUNT=10").OccurrencesLimited(from, 5)
+	require.NoError(t, err)
+	assert.Len(t, occurrences, 4)
+
+	occurrences, err = event.OccurrencesLimited(from, 0)
+	require.NoError(t, err)
+	assert.Empty(t, occurrences)
+}
+
+func TestUpcomingAlarms(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:a
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+RRULE:FREQ=DAILY;COUNT=5
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER:-PT15M
+REPEAT:1
+DURATION:PT5M
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:b
+DTSTART:20240103T120000Z
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER;RELATED=START:-P1D
+END:VALARM
+BEGIN:VALARM
+ACTION:DISPLAY
+TRIGGER;VALUE=DATE-TIME:20240102T000000Z
+END:VALARM
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	alarms := cal.UpcomingAlarms(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 3, 8, 50, 0, 0, time.UTC))
+	var got []string
+	for _, a := range alarms {
+		got = append(got, a.FireTime.Format(icalTimestampFormatUtc)+" "+a.Event.Id()+" "+a.Occurrence.Start.Format(icalTimestampFormatUtc))
+	}
+	assert.Equal(t, []string{
+		"20240102T000000Z b 20240103T120000Z",
+		"20240102T084500Z a 20240102T090000Z",
+		"20240102T085000Z a 20240102T090000Z",
+		"20240102T120000Z b 20240103T120000Z",
+		"20240103T084500Z a 20240103T090000Z",
+	}, got)
+}
+
+func TestRecurrenceNeverMatchingIsFast(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VEVENT
+UID:never
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+RRULE:FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30
+END:VEVENT
+BEGIN:VEVENT
+UID:rare
+DTSTART:20240101T090000Z
+DTEND:20240101T100000Z
+RRULE:FREQ=DAILY;BYMONTH=2;BYMONTHDAY=29
+END:VEVENT
+BEGIN:VEVENT
+UID:counted
+DTSTART:20240101T090000Z
+RRULE:FREQ=MONTHLY;BYMONTH=4;BYMONTHDAY=-31;COUNT=3
+END:VEVENT
+END:VCALENDAR
+`))
+	require.NoError(t, err)
+	day := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	started := time.Now()
+	assert.Empty(t, cal.BusyIntervals(day, time.UTC))
+	count, err := cal.Events()[1].CountOccurrences(day, day.AddDate(4, 0, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	last, ok, err := cal.Events()[2].LastOccurrence()
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), last)
+	assert.Less(t, time.Since(started), 2*time.Second)
+}
+
+func TestFormatUntil(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	until := time.Date(2024, 3, 1, 9, 0, 0, 0, loc)
+	for dtstart, expected := range map[string]string{
+		"DTSTART;TZID=Europe/Berlin:20240101T090000": "20240301T080000Z",
+		"DTSTART:20240101T080000Z":                   "20240301T080000Z",
+		"DTSTART:20240101T090000":                    "20240301T090000",
+		"DTSTART;VALUE=DATE:20240101":                "20240301",
+	} {
+		cal, err := ParseCalendar(strings.NewReader("BEGIN:VCALENDAR\nBEGIN:VEVENT\nUID:a\n" + dtstart + "\nEND:VEVENT\nEND:VCALENDAR\n"))
+		require.NoError(t, err)
+		event := cal.Events()[0]
+		assert.Equal(t, expected, event.FormatUntil(until), dtstart)
+
+		event.AddRrule("FREQ=MONTHLY;UNTIL=" + event.FormatUntil(until))
+		occurrences, err := event.Occurrences(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+		require.NoError(t, err)
+		assert.Len(t, occurrences, 3, dtstart)
+	}
+}
@@ -0,0 +1,354 @@
+package ics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AddMinimalTimezones adds a VTIMEZONE for each TZID referenced by the calendar which does not already have one and can
+// be loaded from the time zone database. Rather than describing the zone's entire history, only the transitions within
+// the years spanned by the values using that TZID are included, keeping the output compact. Note recurrences beyond
+// the last referenced year are not covered. The new timezones are placed before all other components.
+func (cal *Calendar) AddMinimalTimezones() {
+	defined := map[string]bool{}
+	for _, tz := range cal.Timezones() {
+		if p := tz.GetProperty(ComponentPropertyTzid); p != nil {
+			defined[p.Value] = true
+		}
+	}
+	var added []Component
+	for _, tzid := range cal.ReferencedTZIDs() {
+		if defined[tzid] {
+			continue
+		}
+		loc, err := time.LoadLocation(tzid)
+		if err != nil {
+			continue
+		}
+		first, last, ok := cal.tzidTimeRange(tzid, loc)
+		if !ok {
+			continue
+		}
+		from := time.Date(first.Year(), time.January, 1, 0, 0, 0, 0, loc)
+		to := time.Date(last.Year()+1, time.January, 1, 0, 0, 0, 0, loc)
+		added = append(added, newTimezoneFromLocation(tzid, loc, from, to))
+	}
+	cal.Components = append(added, cal.Components...)
+}
+
+// PrimaryTimezone returns the calendar's default time zone, such as for displaying floating times, along with its TZID.
+// X-WR-TIMEZONE is preferred, otherwise the TZID of the calendar's VTIMEZONE if it has exactly one. An error wrapping
+// ErrorPropertyNotFound is returned if neither is available, or wrapping ErrorUnresolvableTZID, along with the TZID, if
+// it is not a known location.
+func (cal *Calendar) PrimaryTimezone() (*time.Location, string, error) {
+	var tzid string
+	if p := cal.getProperty(PropertyXWRTimezone); p != nil && p.Value != "" {
+		tzid = p.Value
+	} else if timezones := cal.Timezones(); len(timezones) == 1 {
+		if p := timezones[0].GetProperty(ComponentPropertyTzid); p != nil {
+			tzid = p.Value
+		}
+	}
+	if tzid == "" {
+		return nil, "", fmt.Errorf("%w: %s or a single VTIMEZONE", ErrorPropertyNotFound, PropertyXWRTimezone)
+	}
+	loc, err := time.LoadLocation(tzid)
+	if err != nil {
+		return nil, tzid, fmt.Errorf("%w: %s", ErrorUnresolvableTZID, tzid)
+	}
+	return loc, tzid, nil
+}
+
+// tzidTimeRange returns the earliest and latest times of all values qualified by tzid
+func (cal *Calendar) tzidTimeRange(tzid string, loc *time.Location) (first time.Time, last time.Time, ok bool) {
+	var walk func(components []Component)
+	walk = func(components []Component) {
+		for _, c := range components {
+			if _, isTimezone := c.(*VTimezone); isTimezone {
+				continue
+			}
+			for _, p := range c.UnknownPropertiesIANAProperties() {
+				if v, err := p.parameterValue(ParameterTzid); err != nil || v != tzid {
+					continue
+				}
+				for _, v := range strings.Split(p.Value, ",") {
+					t, err := time.ParseInLocation(icalTimestampFormatLocal, v, loc)
+					if err != nil {
+						t, err = time.ParseInLocation(icalDateFormatLocal, v, loc)
+					}
+					if err != nil {
+						continue
+					}
+					if !ok || t.Before(first) {
+						first = t
+					}
+					if !ok || t.After(last) {
+						last = t
+					}
+					ok = true
+				}
+			}
+			walk(c.SubComponents())
+		}
+	}
+	walk(cal.Components)
+	return
+}
+
+// newTimezoneFromLocation builds a VTIMEZONE describing loc between from and to, with one STANDARD or DAYLIGHT
+// sub component per observance.
+func newTimezoneFromLocation(tzid string, loc *time.Location, from time.Time, to time.Time) *VTimezone {
+	tz := NewTimezone(tzid)
+	start := from.In(loc)
+	zoneStart, _ := start.ZoneBounds()
+	_, prevOffset := start.Add(-time.Second).Zone()
+	if !zoneStart.IsZero() {
+		start = zoneStart
+		_, prevOffset = zoneStart.Add(-time.Second).Zone()
+	}
+	for {
+		name, offset := start.Zone()
+		var observance *ComponentBase
+		if start.IsDST() {
+			observance = &tz.AddDaylight().ComponentBase
+		} else {
+			observance = &tz.AddStandard().ComponentBase
+		}
+		// DTSTART is the local time of the transition in the offset which was in effect prior to it
+		observance.SetProperty(ComponentPropertyDtStart, start.In(time.FixedZone("", prevOffset)).Format(icalTimestampFormatLocal))
+		observance.SetProperty(ComponentPropertyTzoffsetfrom, formatUtcOffset(prevOffset))
+		observance.SetProperty(ComponentPropertyTzoffsetto, formatUtcOffset(offset))
+		if name != "" {
+			observance.SetProperty(ComponentPropertyTzname, name)
+		}
+		_, end := start.ZoneBounds()
+		if end.IsZero() || !end.Before(to) {
+			break
+		}
+		prevOffset = offset
+		start = end
+	}
+	return tz
+}
+
+// formatUtcOffset formats seconds east of UTC as a UTC-OFFSET value
+func formatUtcOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+	r := fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+	if offset%60 != 0 {
+		r += fmt.Sprintf("%02d", offset%60)
+	}
+	return r
+}
+
+// locationTransitionsEnd bounds the transitions ToLocation generates from observances which recur without an UNTIL
+var locationTransitionsEnd = time.Date(2101, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// zoneTransition is the onset of an observance of a VTIMEZONE
+type zoneTransition struct {
+	at         time.Time
+	name       string
+	offsetFrom int
+	offset     int
+	isDST      bool
+}
+
+// ToLocation builds a *time.Location from the STANDARD and DAYLIGHT observances of the timezone, such as for an
+// Exchange custom TZID which is not in the time zone database. Each observance's onsets are taken from its DTSTART,
+// RRULE and RDATEs, with rules lacking an UNTIL or COUNT followed until the year 2100; later times keep the last offset.
+// A timezone with a single observance gives a location with a fixed offset.
+func (timezone *VTimezone) ToLocation() (*time.Location, error) {
+	tzid := ""
+	if p := timezone.GetProperty(ComponentPropertyTzid); p != nil {
+		tzid = p.Value
+	}
+	var transitions []zoneTransition
+	for _, c := range timezone.Components {
+		var observance *ComponentBase
+		isDST := false
+		switch c := c.(type) {
+		case *Standard:
+			observance = &c.ComponentBase
+		case *Daylight:
+			observance = &c.ComponentBase
+			isDST = true
+		default:
+			continue
+		}
+		ts, err := observance.observanceTransitions(isDST)
+		if err != nil {
+			return nil, fmt.Errorf("timezone %s %s: %w", tzid, c.ComponentType(), err)
+		}
+		transitions = append(transitions, ts...)
+	}
+	if len(transitions) == 0 {
+		return nil, fmt.Errorf("%w: timezone %s has no STANDARD or DAYLIGHT", ErrorPropertyNotFound, tzid)
+	}
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].at.Before(transitions[j].at)
+	})
+	return time.LoadLocationFromTZData(tzid, buildTZif(transitions))
+}
+
+// observanceTransitions returns the onsets of a STANDARD or DAYLIGHT observance
+func (cb *ComponentBase) observanceTransitions(isDST bool) ([]zoneTransition, error) {
+	offsetFrom, err := cb.utcOffsetProperty(ComponentPropertyTzoffsetfrom)
+	if err != nil {
+		return nil, err
+	}
+	offsetTo, err := cb.utcOffsetProperty(ComponentPropertyTzoffsetto)
+	if err != nil {
+		return nil, err
+	}
+	dtstart := cb.GetProperty(ComponentPropertyDtStart)
+	if dtstart == nil {
+		return nil, fmt.Errorf("%w: %s", ErrorPropertyNotFound, ComponentPropertyDtStart)
+	}
+	// Onsets are given in local time in the offset in effect prior to them, which is fixed for an observance
+	before := time.FixedZone("", offsetFrom)
+	start, err := time.ParseInLocation(icalTimestampFormatLocal, dtstart.Value, before)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ComponentPropertyDtStart, err)
+	}
+	name := ""
+	if p := cb.GetProperty(ComponentPropertyTzname); p != nil {
+		name = p.Value
+	}
+	var r []zoneTransition
+	add := func(t time.Time) bool {
+		r = append(r, zoneTransition{at: t, name: name, offsetFrom: offsetFrom, offset: offsetTo, isDST: isDST})
+		return true
+	}
+	rrules := cb.GetProperties(ComponentPropertyRrule)
+	if len(rrules) == 0 {
+		add(start)
+	}
+	for _, p := range rrules {
+		rule, err := parseRecurrenceRule(p.Value, start)
+		if err != nil {
+			return nil, err
+		}
+		if err := rule.expand(start, locationTransitionsEnd, add); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range cb.GetProperties(ComponentPropertyRdate) {
+		for _, v := range strings.Split(p.Value, ",") {
+			t, err := time.ParseInLocation(icalTimestampFormatLocal, v, before)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", ComponentPropertyRdate, err)
+			}
+			add(t)
+		}
+	}
+	return r, nil
+}
+
+// utcOffsetProperty returns the UTC-OFFSET value of a property in seconds east of UTC
+func (cb *ComponentBase) utcOffsetProperty(cp ComponentProperty) (int, error) {
+	p := cb.GetProperty(cp)
+	if p == nil {
+		return 0, fmt.Errorf("%w: %s", ErrorPropertyNotFound, cp)
+	}
+	offset, err := parseUtcOffset(p.Value)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", cp, err)
+	}
+	return offset, nil
+}
+
+// parseUtcOffset parses a UTC-OFFSET value, the inverse of formatUtcOffset
+func parseUtcOffset(s string) (int, error) {
+	if (len(s) != 5 && len(s) != 7) || (s[0] != '+' && s[0] != '-') {
+		return 0, fmt.Errorf("invalid utc offset %q", s)
+	}
+	offset := 0
+	for i, unit := range []int{3600, 60, 1} {
+		if 1+i*2 >= len(s) {
+			break
+		}
+		n, err := strconv.Atoi(s[1+i*2 : 3+i*2])
+		if err != nil {
+			return 0, fmt.Errorf("invalid utc offset %q", s)
+		}
+		offset += n * unit
+	}
+	if s[0] == '-' {
+		offset = -offset
+	}
+	return offset, nil
+}
+
+// buildTZif encodes sorted transitions in the TZif format read by time.LoadLocationFromTZData. Only the 64-bit version 2
+// data is populated; the zone before the first transition takes its offset from that transition.
+func buildTZif(transitions []zoneTransition) []byte {
+	type zoneType struct {
+		offset int
+		isDST  bool
+		name   string
+	}
+	var types []zoneType
+	typeIndex := map[zoneType]int{}
+	var names []byte
+	nameIndex := map[string]int{}
+	addType := func(zt zoneType) int {
+		if i, ok := typeIndex[zt]; ok {
+			return i
+		}
+		if _, ok := nameIndex[zt.name]; !ok {
+			nameIndex[zt.name] = len(names)
+			names = append(append(names, zt.name...), 0)
+		}
+		typeIndex[zt] = len(types)
+		types = append(types, zt)
+		return len(types) - 1
+	}
+	initial := zoneType{offset: transitions[0].offsetFrom}
+	for _, t := range transitions {
+		if t.offset == initial.offset {
+			initial = zoneType{offset: t.offset, isDST: t.isDST, name: t.name}
+			break
+		}
+	}
+	addType(initial)
+	var indexes []byte
+	for _, t := range transitions {
+		indexes = append(indexes, byte(addType(zoneType{offset: t.offset, isDST: t.isDST, name: t.name})))
+	}
+
+	b := &bytes.Buffer{}
+	header := func(counts ...int) {
+		b.WriteString("TZif2")
+		b.Write(make([]byte, 15))
+		for _, n := range counts {
+			_ = binary.Write(b, binary.BigEndian, uint32(n))
+		}
+	}
+	// The empty version 1 data, then the version 2 data
+	header(0, 0, 0, 0, 0, 0)
+	header(0, 0, 0, len(transitions), len(types), len(names))
+	for _, t := range transitions {
+		_ = binary.Write(b, binary.BigEndian, t.at.Unix())
+	}
+	b.Write(indexes)
+	for _, zt := range types {
+		_ = binary.Write(b, binary.BigEndian, int32(zt.offset))
+		isDST := byte(0)
+		if zt.isDST {
+			isDST = 1
+		}
+		b.Write([]byte{isDST, byte(nameIndex[zt.name])})
+	}
+	b.Write(names)
+	b.WriteString("\n\n")
+	return b.Bytes()
+}
@@ -0,0 +1,160 @@
+package ics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddMinimalTimezones(t *testing.T) {
+	cal := NewCalendar()
+	e := cal.AddEvent("test-minimal-timezones")
+	e.SetDtStampTime(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	e.SetProperty(ComponentPropertyDtStart, "20240301T100000", WithTZID("Europe/Copenhagen"))
+	e.SetProperty(ComponentPropertyDtEnd, "20240301T110000", WithTZID("Europe/Copenhagen"))
+	cal.AddMinimalTimezones()
+
+	if !assert.Len(t, cal.Timezones(), 1) {
+		return
+	}
+	assert.Equal(t, cal.Timezones()[0], cal.Components[0])
+	text := strings.ReplaceAll(cal.Timezones()[0].Serialize(defaultSerializationOptions()), "\r\n", "\n")
+	assert.Equal(t, `BEGIN:VTIMEZONE
+TZID:Europe/Copenhagen
+BEGIN:STANDARD
+DTSTART:20231029T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+TZNAME:CET
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:20240331T020000
+TZOFFSETFROM:+0100
+TZOFFSETTO:+0200
+TZNAME:CEST
+END:DAYLIGHT
+BEGIN:STANDARD
+DTSTART:20241027T030000
+TZOFFSETFROM:+0200
+TZOFFSETTO:+0100
+TZNAME:CET
+END:STANDARD
+END:VTIMEZONE
+`, text)
+	assert.NoError(t, cal.Validate())
+
+	cal.AddMinimalTimezones()
+	assert.Len(t, cal.Timezones(), 1)
+}
+
+func TestFormatUtcOffset(t *testing.T) {
+	assert.Equal(t, "+0100", formatUtcOffset(3600))
+	assert.Equal(t, "-0530", formatUtcOffset(-5*3600-30*60))
+	assert.Equal(t, "+0000", formatUtcOffset(0))
+	assert.Equal(t, "+001915", formatUtcOffset(19*60+15))
+}
+
+func TestPrimaryTimezone(t *testing.T) {
+	cal := NewCalendar()
+	_, _, err := cal.PrimaryTimezone()
+	assert.ErrorIs(t, err, ErrorPropertyNotFound)
+
+	cal.AddTimezone("Europe/Paris")
+	loc, tzid, err := cal.PrimaryTimezone()
+	assert.NoError(t, err)
+	assert.Equal(t, "Europe/Paris", tzid)
+	assert.Equal(t, "Europe/Paris", loc.String())
+
+	cal.SetXWRTimezone("Australia/Sydney")
+	loc, tzid, err = cal.PrimaryTimezone()
+	assert.NoError(t, err)
+	assert.Equal(t, "Australia/Sydney", tzid)
+	assert.Equal(t, "Australia/Sydney", loc.String())
+
+	cal.SetXWRTimezone("Nowhere/Special")
+	_, tzid, err = cal.PrimaryTimezone()
+	assert.ErrorIs(t, err, ErrorUnresolvableTZID)
+	assert.Equal(t, "Nowhere/Special", tzid)
+}
+
+func TestVTimezoneToLocation(t *testing.T) {
+	cal, err := ParseCalendar(strings.NewReader(`BEGIN:VCALENDAR
+BEGIN:VTIMEZONE
+TZID:Eastern Standard Time
+BEGIN:STANDARD
+DTSTART:16010101T020000
+TZOFFSETFROM:-0400
+TZOFFSETTO:-0500
+RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=1SU;BYMONTH=11
+END:STANDARD
+BEGIN:DAYLIGHT
+DTSTART:16010101T020000
+TZOFFSETFROM:-0500
+TZOFFSETTO:-0400
+RRULE:FREQ=YEARLY;INTERVAL=1;BYDAY=2SU;BYMONTH=3
+END:DAYLIGHT
+END:VTIMEZONE
+BEGIN:VTIMEZONE
+TZID:India Standard Time
+BEGIN:STANDARD
+DTSTART:16010101T000000
+TZOFFSETFROM:+0530
+TZOFFSETTO:+0530
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VTIMEZONE
+TZID:Broken
+BEGIN:STANDARD
+DTSTART:16010101T000000
+TZOFFSETTO:+0530
+END:STANDARD
+END:VTIMEZONE
+END:VCALENDAR
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	timezones := cal.Timezones()
+
+	loc, err := timezones[0].ToLocation()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "Eastern Standard Time", loc.String())
+	newYork, err := time.LoadLocation("America/New_York")
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, ts := range []string{"20240101T120000Z", "20240310T065959Z", "20240310T070000Z", "20240704T120000Z", "20241103T055959Z", "20241103T060000Z", "20501225T120000Z"} {
+		instant, err := time.Parse(icalTimestampFormatUtc, ts)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, wantOffset := instant.In(newYork).Zone()
+		_, gotOffset := instant.In(loc).Zone()
+		assert.Equal(t, wantOffset, gotOffset, ts)
+	}
+	assert.True(t, time.Date(2024, 7, 4, 12, 0, 0, 0, loc).IsDST())
+
+	loc, err = timezones[1].ToLocation()
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, offset := time.Date(2024, 7, 4, 12, 0, 0, 0, loc).Zone()
+	assert.Equal(t, 5*3600+30*60, offset)
+
+	_, err = timezones[2].ToLocation()
+	assert.ErrorIs(t, err, ErrorPropertyNotFound)
+}
+
+func TestParseUtcOffset(t *testing.T) {
+	for _, offset := range []int{3600, -5*3600 - 30*60, 0, 19*60 + 15} {
+		got, err := parseUtcOffset(formatUtcOffset(offset))
+		assert.NoError(t, err)
+		assert.Equal(t, offset, got)
+	}
+	_, err := parseUtcOffset("0100")
+	assert.Error(t, err)
+}
@@ -0,0 +1,94 @@
+package ics
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// WithRandomSource see GenerateConfiguration.Random
+type WithRandomSource struct {
+	io.Reader
+}
+
+// WithClock see GenerateConfiguration.Clock
+type WithClock func() time.Time
+
+// GenerateConfiguration controls the sources of randomness and time used when generating UIDs and DTSTAMPs, so that
+// tests can produce byte-stable calendars.
+type GenerateConfiguration struct {
+	// Random is read for the random bits of UIDs, crypto/rand.Reader by default. Use a fixed sequence of bytes, or a
+	// seeded math/rand.Rand, for reproducible UIDs.
+	Random io.Reader
+	// Clock returns the current time for DTSTAMP, time.Now by default.
+	Clock func() time.Time
+}
+
+func parseGenerateOps(ops []any) (*GenerateConfiguration, error) {
+	generateConfig := &GenerateConfiguration{
+		Random: rand.Reader,
+		Clock:  time.Now,
+	}
+	for opi, op := range ops {
+		switch op := op.(type) {
+		case WithRandomSource:
+			generateConfig.Random = op.Reader
+		case WithClock:
+			generateConfig.Clock = op
+		case *GenerateConfiguration:
+			return op, nil
+		case error:
+			return nil, op
+		default:
+			return nil, fmt.Errorf("unknown op %d of type %s", opi, reflect.TypeOf(op))
+		}
+	}
+	return generateConfig, nil
+}
+
+// NewUID returns a random version 4 UUID suitable for a UID, read from WithRandomSource if given.
+func NewUID(ops ...any) (string, error) {
+	generateConfig, err := parseGenerateOps(ops)
+	if err != nil {
+		return "", err
+	}
+	return generateConfig.newUID()
+}
+
+func (generateConfig *GenerateConfiguration) newUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := io.ReadFull(generateConfig.Random, b); err != nil {
+		return "", fmt.Errorf("generating uid: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// NewGeneratedEvent returns an event with a UID from NewUID and DTSTAMP set to the current time, taken from WithClock
+// if given.
+func NewGeneratedEvent(ops ...any) (*VEvent, error) {
+	generateConfig, err := parseGenerateOps(ops)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := generateConfig.newUID()
+	if err != nil {
+		return nil, err
+	}
+	event := NewEvent(uid)
+	event.SetDtStampTime(generateConfig.Clock())
+	return event, nil
+}
+
+// AddGeneratedEvent adds an event created by NewGeneratedEvent to the calendar.
+func (cal *Calendar) AddGeneratedEvent(ops ...any) (*VEvent, error) {
+	event, err := NewGeneratedEvent(ops...)
+	if err != nil {
+		return nil, err
+	}
+	cal.AddVEvent(event)
+	return event, nil
+}
@@ -0,0 +1,47 @@
+package ics
+
+import (
+	"bytes"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUID(t *testing.T) {
+	uid, err := NewUID(WithRandomSource{bytes.NewReader(bytes.Repeat([]byte{0xff}, 16))})
+	assert.NoError(t, err)
+	assert.Equal(t, "ffffffff-ffff-4fff-bfff-ffffffffffff", uid)
+
+	uid, err = NewUID()
+	assert.NoError(t, err)
+	assert.Regexp(t, regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$"), uid)
+
+	_, err = NewUID(WithRandomSource{strings.NewReader("short")})
+	assert.Error(t, err)
+}
+
+func TestAddGeneratedEventReproducible(t *testing.T) {
+	clock := WithClock(func() time.Time {
+		return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	})
+	generate := func() string {
+		cal := NewCalendar()
+		random := WithRandomSource{rand.New(rand.NewSource(42))}
+		for i := 0; i < 2; i++ {
+			_, err := cal.AddGeneratedEvent(random, clock)
+			assert.NoError(t, err)
+		}
+		assert.NotEqual(t, cal.Events()[0].Id(), cal.Events()[1].Id())
+		return cal.Serialize()
+	}
+	first := generate()
+	assert.Equal(t, first, generate())
+	assert.Contains(t, first, "DTSTAMP:20240102T030405Z")
+
+	_, err := NewGeneratedEvent(42)
+	assert.Error(t, err)
+}
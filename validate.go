@@ -0,0 +1,124 @@
+package ics
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Validate checks the calendar against the rules this package knows about. All violations found are returned joined
+// together, or nil if there are none. Events are checked for the properties required by the calendar's METHOD, so
+// scheduling messages are held to RFC5546 rather than RFC5545. The rules are incomplete; happy to take PRs adding more
+// with reference to the RFC.
+func (cal *Calendar) Validate() error {
+	var errs []error
+	errs = append(errs, cal.validateTZIDs()...)
+	errs = append(errs, cal.validateEventProperties()...)
+	for _, event := range cal.Events() {
+		errs = append(errs, event.ValidateAttendees()...)
+	}
+	return errors.Join(errs...)
+}
+
+// validateEventProperties ensures each VEVENT has the properties required of it, as given by
+// ComponentProperty.RequiredFor with the calendar's METHOD.
+func (cal *Calendar) validateEventProperties() []error {
+	method := cal.GetMethod()
+	var errs []error
+	for i, event := range cal.Events() {
+		for _, cp := range eventRequiredProperties(method) {
+			if !event.HasProperty(cp) {
+				errs = append(errs, fmt.Errorf("%w: event %d %q has no %s%s", ErrorMissingRequiredProperty, i, event.Id(), cp, methodSuffix(method)))
+			}
+		}
+	}
+	return errs
+}
+
+// methodSuffix describes the METHOD for inclusion in an error message
+func methodSuffix(method Method) string {
+	if method == "" {
+		return ""
+	}
+	return " for METHOD:" + string(method)
+}
+
+// validateTZIDs ensures every referenced TZID is either defined by an embedded VTIMEZONE or can be loaded from the time
+// zone database.
+func (cal *Calendar) validateTZIDs() []error {
+	defined := map[string]bool{}
+	for _, tz := range cal.Timezones() {
+		if p := tz.GetProperty(ComponentPropertyTzid); p != nil {
+			defined[p.Value] = true
+		}
+	}
+	var errs []error
+	for _, tzid := range cal.ReferencedTZIDs() {
+		if defined[tzid] {
+			continue
+		}
+		if _, err := time.LoadLocation(tzid); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %q has no VTIMEZONE and could not be loaded: %v", ErrorUnresolvableTZID, tzid, err))
+		}
+	}
+	return errs
+}
+
+// ValidateAttendees checks that each ATTENDEE and ORGANIZER of the component is a cal-address which can be delivered to,
+// being a mailto: URI with a single plain email address or a URI with another scheme known to be used for calendar
+// users. An error is returned for each which is not.
+func (cb *ComponentBase) ValidateAttendees() []error {
+	var errs []error
+	for _, p := range cb.Properties {
+		if p.IANAToken != string(ComponentPropertyAttendee) && p.IANAToken != string(ComponentPropertyOrganizer) {
+			continue
+		}
+		if err := validateCalAddress(p.Value); err != nil {
+			errs = append(errs, fmt.Errorf("%w: %s %q: %v", ErrorInvalidCalAddress, p.IANAToken, p.Value, err))
+		}
+	}
+	return errs
+}
+
+// calAddressSchemes are the URI schemes other than mailto accepted for a cal-address
+var calAddressSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+	"ldap":  true,
+	"sip":   true,
+	"sips":  true,
+	"tel":   true,
+	"urn":   true,
+}
+
+// validateCalAddress returns why s is not a usable cal-address, or nil if it is
+func validateCalAddress(s string) error {
+	if !calAddressScheme.MatchString(s) {
+		return errors.New("no URI scheme such as mailto:")
+	}
+	scheme, rest, _ := strings.Cut(s, ":")
+	scheme = strings.ToLower(scheme)
+	if scheme == "mailto" {
+		address, err := mail.ParseAddress(rest)
+		if err != nil {
+			return err
+		}
+		if address.Name != "" || address.Address != rest {
+			return errors.New("not a plain email address")
+		}
+		return nil
+	}
+	if !calAddressSchemes[scheme] {
+		return fmt.Errorf("unknown scheme %q", scheme)
+	}
+	if _, err := url.Parse(s); err != nil {
+		return err
+	}
+	if rest == "" {
+		return errors.New("empty address")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package ics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTZIDs(t *testing.T) {
+	cal := NewCalendar()
+	e := cal.AddEvent("test-validate-tzids")
+	e.SetDtStampTime(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	e.SetProperty(ComponentPropertyDtStart, "20240301T100000", WithTZID("Europe/Copenhagen"))
+	assert.NoError(t, cal.Validate())
+
+	e.SetProperty(ComponentPropertyDtEnd, "20240301T110000", WithTZID("Customized Time Zone"))
+	err := cal.Validate()
+	assert.ErrorIs(t, err, ErrorUnresolvableTZID)
+	assert.Contains(t, err.Error(), "Customized Time Zone")
+
+	cal.AddTimezone("Customized Time Zone")
+	assert.NoError(t, cal.Validate())
+}
+
+func TestValidateMethodRequiredProperties(t *testing.T) {
+	cal := NewCalendar()
+	e := cal.AddEvent("test-validate-method")
+	err := cal.Validate()
+	assert.ErrorIs(t, err, ErrorMissingRequiredProperty)
+	assert.Contains(t, err.Error(), "DTSTART")
+
+	cal.SetMethod(MethodReply)
+	assert.Equal(t, MethodReply, cal.GetMethod())
+	err = cal.Validate()
+	assert.ErrorIs(t, err, ErrorMissingRequiredProperty)
+	assert.NotContains(t, err.Error(), "DTSTART")
+	assert.Contains(t, err.Error(), "ORGANIZER for METHOD:REPLY")
+
+	e.SetDtStampTime(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	e.SetOrganizer("organizer@example.com")
+	e.AddAttendee("attendee@example.com", ParticipationStatusAccepted)
+	assert.NoError(t, cal.Validate())
+
+	cal.SetMethod("request")
+	assert.Equal(t, MethodRequest, cal.GetMethod())
+	err = cal.Validate()
+	assert.ErrorIs(t, err, ErrorMissingRequiredProperty)
+	assert.Contains(t, err.Error(), "DTSTART")
+	assert.Contains(t, err.Error(), "SUMMARY")
+
+	assert.Equal(t, Method(""), NewCalendar().GetMethod())
+
+	assert.True(t, ComponentPropertyDtStart.Required(e))
+	assert.True(t, ComponentPropertyDtstamp.Required(e))
+	assert.False(t, ComponentPropertyDtStart.RequiredFor(e, MethodReply))
+	assert.True(t, ComponentPropertyAttendee.RequiredFor(e, MethodReply))
+	assert.False(t, ComponentPropertyAttendee.Required(e))
+	assert.False(t, ComponentPropertyUniqueId.Required(NewTodo("test-validate-todo")))
+}
+
+func TestValidateAttendees(t *testing.T) {
+	cal := NewCalendar()
+	e := cal.AddEvent("test-validate-attendees")
+	e.SetDtStampTime(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	e.SetStartAt(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	e.SetOrganizer("organizer@example.com")
+	e.AddAttendee("a@example.com")
+	e.AddAttendee("urn:uuid:2f7e3c1e-3b8f-4b7a-9a0e-9d5c1f1e2a3b")
+	assert.Empty(t, e.ValidateAttendees())
+	assert.NoError(t, cal.Validate())
+
+	e.AddAttendee("b@example..com")
+	e.AddProperty(ComponentPropertyAttendee, "c@example.com")
+	e.AddAttendee("Dee <d@example.com>")
+	e.AddAttendee("gopher:example.com")
+	errs := e.ValidateAttendees()
+	assert.Len(t, errs, 4)
+	for _, err := range errs {
+		assert.ErrorIs(t, err, ErrorInvalidCalAddress)
+	}
+	assert.ErrorIs(t, cal.Validate(), ErrorInvalidCalAddress)
+
+	valid := map[string]bool{}
+	for _, attendee := range e.Attendees() {
+		valid[attendee.CalAddress()] = attendee.IsValid()
+	}
+	assert.Equal(t, map[string]bool{
+		"mailto:a@example.com":                          true,
+		"urn:uuid:2f7e3c1e-3b8f-4b7a-9a0e-9d5c1f1e2a3b": true,
+		"mailto:b@example..com":                         false,
+		"c@example.com":                                 false,
+		"mailto:Dee <d@example.com>":                    false,
+		"gopher:example.com":                            false,
+	}, valid)
+}